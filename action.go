@@ -38,6 +38,9 @@ type Action struct {
 
 	// Middlewares is a list of middlewares that need to be applied to this action only
 	Middlewares []MiddlewareBuilder
+
+	// Doc documents this action in the OpenAPI document
+	Doc *RouteDoc
 }
 
 // ActionFunc is a function that processes an HTTP request and returns either:
@@ -61,6 +64,7 @@ type action struct {
 	template   Template
 	impl       ActionFunc
 	translator *i18n.Translator
+	route      *Route
 }
 
 func (a *action) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -258,6 +262,15 @@ func (a *action) renderHTML(r *http.Request, w http.ResponseWriter, resp *Respon
 		"csrf_field": func() template.HTML {
 			return CSRFField(r)
 		},
+		"csp_nonce": func() string {
+			return CSPNonceFromContext(r)
+		},
+		"path": func(name string, params ...any) (string, error) {
+			return a.route.router.urlFor(a.route.Version, name, params...)
+		},
+		"url": func(name string, params ...any) (string, error) {
+			return a.absoluteURL(r, name, params...)
+		},
 	})
 
 	// TODO: Write to a buffer and only write to the response on success
@@ -0,0 +1,33 @@
+package tracks
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/tmeire/tracks/database"
+	"github.com/tmeire/tracks/session"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AuditActor attributes the changes to audited models during a request to the authenticated user
+// of the session, the domain of the request and the request ID. The request ID is taken from the
+// X-Request-ID header, or the trace ID of the request when the header is missing.
+func AuditActor(next http.Handler) (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" {
+			if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+				requestID = sc.TraceID().String()
+			}
+		}
+
+		ctx := database.WithAuditActor(r.Context(), func(ctx context.Context) database.AuditActor {
+			actor := database.AuditActor{RequestID: requestID}
+			if s := session.FromContext(ctx); s != nil {
+				actor.UserID, _ = s.Authenticated()
+			}
+			return actor
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}), nil
+}
@@ -0,0 +1,82 @@
+package tracks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/tmeire/tracks/database"
+)
+
+// BackupJob is a job that backs up databases with database.BackupFile. Each database is backed up
+// to its own directory in Dir. When Interval and Queue are set, the job enqueues itself again
+// after every run, so backups run periodically inside the application:
+//
+//	job := tracks.NewBackupJob(r, "backups", 24*time.Hour, database.BackupOptions{Compress: true, Retain: 7})
+//	err := job.Schedule(ctx, nextMidnight)
+type BackupJob struct {
+	Dir     string
+	Options database.BackupOptions
+	// Databases returns the files of the databases to back up, by name. The name is the directory
+	// of the database's backups in Dir.
+	Databases func(ctx context.Context) (map[string]string, error)
+	Interval  time.Duration
+	Queue     Queue
+}
+
+// NewBackupJob creates a job that backs up the database of the router to <dir>/central every
+// interval. Set Databases to back up other databases as well, e.g. the tenant databases.
+func NewBackupJob(r Router, dir string, interval time.Duration, opts database.BackupOptions) *BackupJob {
+	db := r.Database()
+	return &BackupJob{
+		Dir:     dir,
+		Options: opts,
+		Databases: func(ctx context.Context) (map[string]string, error) {
+			path, err := database.FilePath(ctx, db)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]string{"central": path}, nil
+		},
+		Interval: interval,
+		Queue:    r.Queue(),
+	}
+}
+
+// Schedule enqueues the first run of the job at the given time
+func (j *BackupJob) Schedule(ctx context.Context, at time.Time) error {
+	if j.Queue == nil {
+		return errors.New("backup job has no queue, configure a jobs driver")
+	}
+	return j.Queue.EnqueueAt(ctx, at, j)
+}
+
+// Handle backs up all databases. A failing database doesn't stop the others, all errors are
+// returned together.
+func (j *BackupJob) Handle(ctx context.Context) error {
+	if j.Interval > 0 && j.Queue != nil {
+		// Schedule the next run first, so a failing run doesn't stop the backups
+		if err := j.Queue.EnqueueAt(ctx, time.Now().Add(j.Interval), j); err != nil {
+			slog.ErrorContext(ctx, "Failed to schedule the next backup", "error", err)
+		}
+	}
+
+	files, err := j.Databases(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list databases to back up: %w", err)
+	}
+
+	var errs []error
+	for name, file := range files {
+		path, err := database.BackupFile(ctx, file, filepath.Join(j.Dir, name), j.Options)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to back up %s: %w", name, err))
+			continue
+		}
+		slog.InfoContext(ctx, "Backed up database", "database", name, "backup", path)
+	}
+	return errors.Join(errs...)
+}
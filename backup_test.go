@@ -0,0 +1,55 @@
+package tracks
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmeire/tracks/database"
+)
+
+// recordingQueue records the jobs that are enqueued
+type recordingQueue struct {
+	Queue
+	at []time.Time
+}
+
+func (q *recordingQueue) EnqueueAt(ctx context.Context, at time.Time, job Job) error {
+	q.at = append(q.at, at)
+	return nil
+}
+
+func TestBackupJob(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "tracks.sqlite")
+
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE products (id INTEGER PRIMARY KEY, name TEXT)")
+	require.NoError(t, err)
+
+	queue := &recordingQueue{}
+	job := &BackupJob{
+		Dir: filepath.Join(dir, "backups"),
+		Databases: func(ctx context.Context) (map[string]string, error) {
+			return map[string]string{"central": dbPath, "missing": filepath.Join(dir, "missing.sqlite")}, nil
+		},
+		Interval: time.Hour,
+		Queue:    queue,
+	}
+
+	err = job.Handle(context.Background())
+	assert.ErrorContains(t, err, "failed to back up missing")
+
+	backups, err := database.Backups(filepath.Join(dir, "backups", "central"))
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+
+	require.Len(t, queue.at, 1, "the next run is scheduled, even when a backup failed")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), queue.at[0], time.Minute)
+}
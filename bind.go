@@ -0,0 +1,414 @@
+package tracks
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBindIndex limits the index of indexed form keys, like items[0].qty, so a request can't
+// allocate huge slices
+const maxBindIndex = 1000
+
+// maxMultipartMemory is the memory multipart forms are parsed in, larger files are stored on disk
+const maxMultipartMemory = 32 << 20
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	fileHeaderType      = reflect.TypeOf(&multipart.FileHeader{})
+)
+
+// timeLayouts are the layouts times are parsed with, including the values of the date and
+// datetime-local inputs
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+// Bind fills the struct dst points to from the request, based on the tags of its fields:
+//
+//	type UpdateOrder struct {
+//		ID        int         `path:"id"`
+//		Page      int         `query:"page"`
+//		Token     string      `header:"X-Token"`
+//		Session   string      `cookie:"session"`
+//		Note      string      `form:"note" json:"note"`
+//		DeliverAt time.Time   `form:"deliver_at" json:"deliver_at"`
+//		Items     []OrderItem `form:"items" json:"items"`
+//	}
+//
+// JSON bodies are decoded with the json tags, url-encoded and multipart bodies with the form tags.
+// Form fields of nested structs and slices use keys like address.city and items[0].qty, and
+// *multipart.FileHeader fields receive uploaded files. Besides strings, numbers and booleans,
+// fields can be time.Time, pointers, slices and types that implement encoding.TextUnmarshaler.
+//
+// Values that can't be converted to their field are reported as ValidationErrors, by key. Bind
+// doesn't validate the struct, call Validate for that.
+func Bind(r *http.Request, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dst must be a pointer to a struct")
+	}
+	rv = rv.Elem()
+
+	errs := make(ValidationErrors)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		if err := bindJSON(r.Body, dst, errs); err != nil {
+			return err
+		}
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
+			return AppError{StatusCode: http.StatusBadRequest, Code: "BAD_REQUEST", Message: err.Error()}
+		}
+		bindForm(rv, "", newFormKeys(r.PostForm, r.MultipartForm.File), errs)
+	default:
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if err := r.ParseForm(); err != nil {
+				return AppError{StatusCode: http.StatusBadRequest, Code: "BAD_REQUEST", Message: err.Error()}
+			}
+			bindForm(rv, "", newFormKeys(r.PostForm, nil), errs)
+		}
+	}
+
+	bindRequest(r, rv, errs)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// bindJSON decodes a JSON body, values of the wrong type are reported as field errors
+func bindJSON(body io.Reader, dst any, errs ValidationErrors) error {
+	err := json.NewDecoder(body).Decode(dst)
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		errs[typeErr.Field] = append(errs[typeErr.Field], "must be a "+typeName(typeErr.Type))
+		return nil
+	}
+	return AppError{StatusCode: http.StatusBadRequest, Code: "BAD_REQUEST", Message: err.Error()}
+}
+
+// bindRequest fills the fields with path, query, header and cookie tags
+func bindRequest(r *http.Request, rv reflect.Value, errs ValidationErrors) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			bindRequest(r, fv, errs)
+			continue
+		}
+		if !fv.CanSet() {
+			continue
+		}
+
+		if name := field.Tag.Get("path"); name != "" && name != "-" {
+			if v := r.PathValue(name); v != "" {
+				setValues(fv, name, []string{v}, errs)
+			}
+		}
+		if name := field.Tag.Get("query"); name != "" && name != "-" {
+			if v, ok := r.URL.Query()[name]; ok {
+				setValues(fv, name, v, errs)
+			}
+		}
+		if name := field.Tag.Get("header"); name != "" && name != "-" {
+			if v := r.Header.Values(name); len(v) > 0 {
+				setValues(fv, name, v, errs)
+			}
+		}
+		if name := field.Tag.Get("cookie"); name != "" && name != "-" {
+			if c, err := r.Cookie(name); err == nil {
+				setValues(fv, name, []string{c.Value}, errs)
+			}
+		}
+	}
+}
+
+// formKeys holds the values and files of a form, with an index of their keys that's built once
+// per Bind, so nested structs and slices don't scan all keys of the form
+type formKeys struct {
+	values url.Values
+	files  map[string][]*multipart.FileHeader
+
+	// nested holds every key, and every part of a key that ends before a . or [
+	nested map[string]bool
+	// last holds the highest index of the indexed keys of a slice key, e.g. 1 for items when the
+	// form has items[0].qty and items[1].qty
+	last map[string]int
+}
+
+func newFormKeys(values url.Values, files map[string][]*multipart.FileHeader) *formKeys {
+	f := &formKeys{
+		values: values,
+		files:  files,
+		nested: make(map[string]bool),
+		last:   make(map[string]int),
+	}
+	for k := range values {
+		f.add(k)
+	}
+	for k := range files {
+		f.add(k)
+	}
+	return f
+}
+
+// add adds a key and the keys it's nested under to the index
+func (f *formKeys) add(k string) {
+	f.nested[k] = true
+	for i := 0; i < len(k); i++ {
+		if k[i] != '.' && k[i] != '[' {
+			continue
+		}
+		parent := k[:i]
+		f.nested[parent] = true
+		if k[i] != '[' {
+			continue
+		}
+
+		idx, _, ok := strings.Cut(k[i+1:], "]")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(idx)
+		if err != nil || n < 0 {
+			continue
+		}
+		if last, ok := f.last[parent]; !ok || n > last {
+			f.last[parent] = n
+		}
+	}
+}
+
+// bindForm fills the fields with form tags of a struct, whose keys start with prefix
+func bindForm(rv reflect.Value, prefix string, form *formKeys, errs ValidationErrors) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			bindForm(fv, prefix, form, errs)
+			continue
+		}
+
+		name := field.Tag.Get("form")
+		if name == "" || name == "-" || !fv.CanSet() {
+			continue
+		}
+		bindFormValue(fv, prefix+name, form, errs)
+	}
+}
+
+// bindFormValue fills a single value from the form values with the given key, or the keys nested
+// under it
+func bindFormValue(fv reflect.Value, key string, form *formKeys, errs ValidationErrors) {
+	t := fv.Type()
+
+	switch {
+	case t == fileHeaderType:
+		if f := form.files[key]; len(f) > 0 {
+			fv.Set(reflect.ValueOf(f[0]))
+		}
+		return
+	case t.Kind() == reflect.Slice && t.Elem() == fileHeaderType:
+		if f := form.files[key]; len(f) > 0 {
+			fv.Set(reflect.ValueOf(f))
+		}
+		return
+	case isScalar(t):
+		if v, ok := form.values[key]; ok {
+			setValues(fv, key, v, errs)
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		if !form.nested[key] {
+			return
+		}
+		if fv.IsNil() {
+			fv.Set(reflect.New(t.Elem()))
+		}
+		bindFormValue(fv.Elem(), key, form, errs)
+	case reflect.Struct:
+		bindForm(fv, key+".", form, errs)
+	case reflect.Slice:
+		// Repeated keys, like tags=a&tags=b, or tags[]=a&tags[]=b
+		if isScalar(t.Elem()) {
+			v, ok := form.values[key]
+			if !ok {
+				v, ok = form.values[key+"[]"]
+			}
+			if ok {
+				setValues(fv, key, v, errs)
+				return
+			}
+		}
+
+		// Indexed keys, like items[0].qty=1&items[1].qty=2
+		n, ok := form.last[key]
+		if !ok {
+			return
+		}
+		if n >= maxBindIndex {
+			errs[key] = append(errs[key], fmt.Sprintf("must have less than %d items", maxBindIndex))
+			return
+		}
+		slice := reflect.MakeSlice(t, n+1, n+1)
+		for i := 0; i <= n; i++ {
+			bindFormValue(slice.Index(i), fmt.Sprintf("%s[%d]", key, i), form, errs)
+		}
+		fv.Set(slice)
+	}
+}
+
+// isScalar reports whether a type is set from a single value
+func isScalar(t reflect.Type) bool {
+	if t == timeType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Ptr:
+		return isScalar(t.Elem())
+	}
+	return false
+}
+
+// setValues sets a scalar, a pointer to a scalar or a slice of scalars from the values of a key
+func setValues(fv reflect.Value, key string, values []string, errs ValidationErrors) {
+	t := fv.Type()
+	if t.Kind() == reflect.Slice && isScalar(t.Elem()) {
+		slice := reflect.MakeSlice(t, len(values), len(values))
+		for i, v := range values {
+			if err := setValue(slice.Index(i), v); err != nil {
+				errs[key] = append(errs[key], err.Error())
+				return
+			}
+		}
+		fv.Set(slice)
+		return
+	}
+	if !isScalar(t) || len(values) == 0 {
+		return
+	}
+	if err := setValue(fv, values[0]); err != nil {
+		errs[key] = append(errs[key], err.Error())
+	}
+}
+
+// setValue converts a single value to the type of fv. Empty values leave numbers, booleans and
+// times unset, like empty form inputs.
+func setValue(fv reflect.Value, s string) error {
+	if fv.Kind() == reflect.Ptr {
+		if s == "" && fv.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		v := reflect.New(fv.Type().Elem())
+		if err := setValue(v.Elem(), s); err != nil {
+			return err
+		}
+		fv.Set(v)
+		return nil
+	}
+
+	if fv.Type() == timeType {
+		if s == "" {
+			return nil
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				fv.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("must be a time")
+	}
+	if u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if s == "" {
+			return nil
+		}
+		if err := u.UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("is invalid")
+		}
+		return nil
+	}
+
+	if s == "" && fv.Kind() != reflect.String {
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			// Checkboxes are sent as "on"
+			if s != "on" {
+				return fmt.Errorf("must be true or false")
+			}
+			b = true
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a positive integer")
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		fv.SetFloat(f)
+	}
+	return nil
+}
+
+// typeName describes a Go type in field errors of JSON bodies
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	default:
+		return "object"
+	}
+}
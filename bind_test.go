@@ -0,0 +1,179 @@
+package tracks
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type OrderItem struct {
+	SKU string `form:"sku" json:"sku"`
+	Qty uint   `form:"qty" json:"qty"`
+}
+
+type Address struct {
+	City string `form:"city" json:"city"`
+}
+
+type UpdateOrder struct {
+	ID        int         `path:"id"`
+	Page      *int        `query:"page"`
+	Filters   []string    `query:"filter"`
+	Token     string      `header:"X-Token"`
+	Session   string      `cookie:"session"`
+	Note      string      `form:"note" json:"note"`
+	Express   bool        `form:"express" json:"express"`
+	DeliverAt time.Time   `form:"deliver_at" json:"deliver_at"`
+	IP        netip.Addr  `form:"ip" json:"ip"`
+	Address   *Address    `form:"address" json:"address"`
+	Items     []OrderItem `form:"items" json:"items"`
+	Tags      []int       `form:"tags" json:"tags"`
+}
+
+func bindOrder(t *testing.T, req *http.Request) (UpdateOrder, error) {
+	t.Helper()
+
+	var order UpdateOrder
+	var err error
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		err = Bind(r, &order)
+	})
+
+	req.Header.Set("X-Token", "secret")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+	return order, err
+}
+
+func TestBind(t *testing.T) {
+	t.Run("Binds path, query, header, cookie and form values", func(t *testing.T) {
+		form := url.Values{
+			"note":           {"Ring twice"},
+			"express":        {"on"},
+			"deliver_at":     {"2026-05-01T14:30"},
+			"ip":             {"10.0.0.1"},
+			"address.city":   {"Ghent"},
+			"items[0].sku":   {"A1"},
+			"items[0].qty":   {"2"},
+			"items[1].sku":   {"B2"},
+			"items[1].qty":   {"5"},
+			"tags[]":         {"1", "2"},
+			"ignored[0].sku": {"C3"},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/orders/7?page=3&filter=open&filter=paid", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		order, err := bindOrder(t, req)
+		require.NoError(t, err)
+
+		assert.Equal(t, 7, order.ID)
+		require.NotNil(t, order.Page)
+		assert.Equal(t, 3, *order.Page)
+		assert.Equal(t, []string{"open", "paid"}, order.Filters)
+		assert.Equal(t, "secret", order.Token)
+		assert.Equal(t, "abc", order.Session)
+		assert.Equal(t, "Ring twice", order.Note)
+		assert.True(t, order.Express)
+		assert.Equal(t, time.Date(2026, 5, 1, 14, 30, 0, 0, time.UTC), order.DeliverAt)
+		assert.Equal(t, netip.MustParseAddr("10.0.0.1"), order.IP)
+		require.NotNil(t, order.Address)
+		assert.Equal(t, "Ghent", order.Address.City)
+		assert.Equal(t, []OrderItem{{SKU: "A1", Qty: 2}, {SKU: "B2", Qty: 5}}, order.Items)
+		assert.Equal(t, []int{1, 2}, order.Tags)
+	})
+
+	t.Run("Binds JSON bodies", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/orders/7", strings.NewReader(`{"note":"Leave at the door","items":[{"sku":"A1","qty":1}]}`))
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+		order, err := bindOrder(t, req)
+		require.NoError(t, err)
+		assert.Equal(t, 7, order.ID)
+		assert.Equal(t, "Leave at the door", order.Note)
+		assert.Equal(t, []OrderItem{{SKU: "A1", Qty: 1}}, order.Items)
+		assert.Nil(t, order.Address)
+	})
+
+	t.Run("Binds multipart forms", func(t *testing.T) {
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		require.NoError(t, w.WriteField("note", "With a file"))
+		require.NoError(t, w.WriteField("items[0].sku", "A1"))
+		f, err := w.CreateFormFile("invoice", "invoice.pdf")
+		require.NoError(t, err)
+		f.Write([]byte("%PDF"))
+		require.NoError(t, w.Close())
+
+		var upload struct {
+			Note    string                `form:"note"`
+			Items   []OrderItem           `form:"items"`
+			Invoice *multipart.FileHeader `form:"invoice"`
+		}
+		req := httptest.NewRequest(http.MethodPost, "/", &body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		require.NoError(t, Bind(req, &upload))
+
+		assert.Equal(t, "With a file", upload.Note)
+		assert.Equal(t, []OrderItem{{SKU: "A1"}}, upload.Items)
+		require.NotNil(t, upload.Invoice)
+		assert.Equal(t, "invoice.pdf", upload.Invoice.Filename)
+	})
+
+	t.Run("Reports the values that can't be converted", func(t *testing.T) {
+		form := url.Values{
+			"items[0].qty": {"-1"},
+			"deliver_at":   {"tomorrow"},
+			"ip":           {"localhost"},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/orders/seven?page=x", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		_, err := bindOrder(t, req)
+		var errs ValidationErrors
+		require.ErrorAs(t, err, &errs)
+		assert.Equal(t, ValidationErrors{
+			"id":           {"must be an integer"},
+			"page":         {"must be an integer"},
+			"items[0].qty": {"must be a positive integer"},
+			"deliver_at":   {"must be a time"},
+			"ip":           {"is invalid"},
+		}, errs)
+	})
+
+	t.Run("Reports JSON values of the wrong type", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/orders/7", strings.NewReader(`{"note":5}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		_, err := bindOrder(t, req)
+		assert.Equal(t, ValidationErrors{"note": {"must be a string"}}, err)
+	})
+
+	t.Run("Rejects malformed JSON", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/orders/7", strings.NewReader(`{"note":`))
+		req.Header.Set("Content-Type", "application/json")
+
+		_, err := bindOrder(t, req)
+		var appErr AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, http.StatusBadRequest, appErr.StatusCode)
+	})
+
+	t.Run("Limits indexed keys", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/orders/7", strings.NewReader("items[5000].qty=1"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		order, err := bindOrder(t, req)
+		assert.Error(t, err)
+		assert.Empty(t, order.Items)
+	})
+}
@@ -15,6 +15,14 @@ func DbCmd() *cobra.Command {
 
 	// Add subcommands
 	dbCmd.AddCommand(db.MigrateCmd())
+	dbCmd.AddCommand(db.RollbackCmd())
+	dbCmd.AddCommand(db.RedoCmd())
+	dbCmd.AddCommand(db.SchemaDumpCmd())
+	dbCmd.AddCommand(db.SchemaLoadCmd())
+	dbCmd.AddCommand(db.SeedCmd())
+	dbCmd.AddCommand(db.ReencryptCmd())
+	dbCmd.AddCommand(db.BackupCmd())
+	dbCmd.AddCommand(db.RestoreCmd())
 
 	return dbCmd
 }
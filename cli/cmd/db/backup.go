@@ -0,0 +1,86 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tmeire/tracks/database"
+)
+
+// BackupFunc backs up the databases a module keeps next to the central database at dbPath, like
+// the databases of tenants, to dir
+type BackupFunc func(cmd *cobra.Command, dbPath, dir string, opts database.BackupOptions) error
+
+var backups []BackupFunc
+
+// RegisterBackup adds the databases of a module to db backup, which calls fn after the central
+// database was backed up
+func RegisterBackup(fn BackupFunc) {
+	backups = append(backups, fn)
+}
+
+// BackupCmd returns a cobra.Command for the db backup command
+func BackupCmd() *cobra.Command {
+	var dbPath string
+	var dir string
+	var modules bool
+	var opts database.BackupOptions
+	backupCmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up the databases",
+		Long: `Back up the central database and the databases of the modules, like the databases of tenants.
+
+The databases are copied with VACUUM INTO, which gives a consistent snapshot while the application
+keeps running. The central database is written to <dir>/central, named after the time of the
+backup.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := database.BackupFile(cmd.Context(), dbPath, filepath.Join(dir, "central"), opts)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Backed up the central database to %s\n", path)
+
+			if !modules {
+				return
+			}
+			for _, backup := range backups {
+				if err := backup(cmd, dbPath, dir, opts); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+		},
+	}
+	backupCmd.Flags().StringVar(&dbPath, "db", filepath.Join(".", "data", "tracks.sqlite"), "Central database path")
+	backupCmd.Flags().StringVar(&dir, "dir", "backups", "Backup directory")
+	backupCmd.Flags().BoolVar(&modules, "modules", true, "Also back up the databases of the modules, like the tenant databases")
+	backupCmd.Flags().BoolVar(&opts.Compress, "compress", true, "Compress the backups with gzip")
+	backupCmd.Flags().IntVar(&opts.Retain, "retain", 0, "Number of backups to keep per database, 0 keeps all")
+	return backupCmd
+}
+
+// RestoreCmd returns a cobra.Command for the db restore command
+func RestoreCmd() *cobra.Command {
+	var dbPath string
+	restoreCmd := &cobra.Command{
+		Use:   "restore [backup]",
+		Short: "Restore the central database from a backup",
+		Long: `Restore the central database from a backup made by db backup.
+
+The backup is checked for integrity before it replaces the database. Stop the application before
+restoring a database.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := database.Restore(cmd.Context(), args[0], dbPath); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Restored %s from %s\n", dbPath, args[0])
+		},
+	}
+	restoreCmd.Flags().StringVar(&dbPath, "db", filepath.Join(".", "data", "tracks.sqlite"), "Central database path")
+	return restoreCmd
+}
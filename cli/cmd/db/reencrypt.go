@@ -0,0 +1,88 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tmeire/tracks"
+	"github.com/tmeire/tracks/database"
+)
+
+// ReencryptCmd returns a cobra.Command for the db reencrypt command
+func ReencryptCmd() *cobra.Command {
+	var dbPath string
+	var configPath string
+	reencryptCmd := &cobra.Command{
+		Use:   "reencrypt",
+		Short: "Re-encrypt attributes with the current key",
+		Long: `Re-encrypt all encrypted attributes that were encrypted with an older key.
+
+The keys are read from secrets.encryption in the application config, which is the file in
+TRACKS_CONFIG_FILE or config/config.json unless --config is set. After a key rotation, run this
+command for every database before removing the old key from the config.
+
+Values that were encrypted with a key that is no longer in the config are left as is and listed.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if configPath == "" {
+				fn, err := tracks.ConfigFileName()
+				if err != nil {
+					cmd.PrintErrf("Error: %v\n", err)
+					return
+				}
+				configPath = fn
+			}
+
+			content, err := os.ReadFile(configPath)
+			if err != nil {
+				cmd.PrintErrf("Error: failed to read config: %v\n", err)
+				return
+			}
+
+			var conf struct {
+				Secrets struct {
+					Encryption []string `json:"encryption"`
+				} `json:"secrets"`
+			}
+			if err := json.Unmarshal(content, &conf); err != nil {
+				cmd.PrintErrf("Error: invalid config: %v\n", err)
+				return
+			}
+
+			keys := make([][]byte, len(conf.Secrets.Encryption))
+			for i, k := range conf.Secrets.Encryption {
+				keys[i] = []byte(k)
+			}
+			if err := database.SetEncryptionKeys(keys...); err != nil {
+				cmd.PrintErrf("Error: %v\n", err)
+				return
+			}
+
+			db, err := sql.Open("sqlite3", dbPath)
+			if err != nil {
+				cmd.PrintErrf("Error: failed to connect to database: %v\n", err)
+				return
+			}
+			defer db.Close()
+
+			n, skipped, err := database.Reencrypt(cmd.Context(), db)
+			if err != nil {
+				cmd.PrintErrf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("Re-encrypted %d values\n", n)
+			if len(skipped) > 0 {
+				cmd.PrintErrf("Skipped %d values that were encrypted with an unknown key:\n", len(skipped))
+				for _, s := range skipped {
+					cmd.PrintErrf("  %s.%s of row %d\n", s.Table, s.Column, s.RowID)
+				}
+			}
+		},
+	}
+	reencryptCmd.Flags().StringVar(&dbPath, "db", filepath.Join(".", "data", "tracks.sqlite"), "Database path")
+	reencryptCmd.Flags().StringVar(&configPath, "config", "", "Application config file (default: TRACKS_CONFIG_FILE or config/config.json)")
+	return reencryptCmd
+}
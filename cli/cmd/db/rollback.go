@@ -0,0 +1,56 @@
+package db
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tmeire/tracks/database"
+)
+
+// RollbackCmd returns a cobra.Command for the db rollback command
+func RollbackCmd() *cobra.Command {
+	var dbType string
+	var dbPath string
+	var steps int
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Revert the most recent migrations",
+		Long:  `Revert the given number of most recent migrations, one by one.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			databaseType, ok := parseType(cmd, dbType)
+			if !ok {
+				return
+			}
+			if err := database.RollbackMigrations(cmd.Context(), databaseType, dbPath, steps); err != nil {
+				cmd.PrintErrf("Error: %v\n", err)
+			}
+		},
+	}
+	rollbackCmd.Flags().StringVar(&dbType, "type", "central", "Database type (central or tenant)")
+	rollbackCmd.Flags().StringVar(&dbPath, "db", filepath.Join(".", "data", "tracks.sqlite"), "Database path")
+	rollbackCmd.Flags().IntVar(&steps, "steps", 1, "Number of migrations to revert")
+	return rollbackCmd
+}
+
+// RedoCmd returns a cobra.Command for the db redo command
+func RedoCmd() *cobra.Command {
+	var dbType string
+	var dbPath string
+	redoCmd := &cobra.Command{
+		Use:   "redo",
+		Short: "Revert and reapply the most recent migration",
+		Long:  `Revert the most recent migration and apply it again, to check that its down migration works.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			databaseType, ok := parseType(cmd, dbType)
+			if !ok {
+				return
+			}
+			if err := database.RunGooseMigration(cmd.Context(), "redo", databaseType, dbPath); err != nil {
+				cmd.PrintErrf("Error: %v\n", err)
+			}
+		},
+	}
+	redoCmd.Flags().StringVar(&dbType, "type", "central", "Database type (central or tenant)")
+	redoCmd.Flags().StringVar(&dbPath, "db", filepath.Join(".", "data", "tracks.sqlite"), "Database path")
+	return redoCmd
+}
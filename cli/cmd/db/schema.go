@@ -0,0 +1,111 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tmeire/tracks/database"
+)
+
+// schemaFile returns the default location of the schema dump. It lives next to the migrations
+// directory instead of inside it, because goose treats every SQL file in there as a migration.
+func schemaFile(dbType database.Type) string {
+	return filepath.Join("migrations", string(dbType)+"_schema.sql")
+}
+
+// SchemaDumpCmd returns a cobra.Command for the db schema:dump command
+func SchemaDumpCmd() *cobra.Command {
+	var dbType string
+	var dbPath string
+	var file string
+	dumpCmd := &cobra.Command{
+		Use:   "schema:dump",
+		Short: "Write the database schema to a file",
+		Long: `Write the schema of a migrated database to a canonical SQL file.
+
+Commit the file with the migrations, so reviewers can see the full schema diff.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			databaseType, ok := parseType(cmd, dbType)
+			if !ok {
+				return
+			}
+			if file == "" {
+				file = schemaFile(databaseType)
+			}
+
+			db, err := sql.Open("sqlite3", dbPath)
+			if err != nil {
+				cmd.PrintErrf("Error: failed to connect to database: %v\n", err)
+				return
+			}
+			defer db.Close()
+
+			f, err := os.Create(file)
+			if err != nil {
+				cmd.PrintErrf("Error: %v\n", err)
+				return
+			}
+			defer f.Close()
+
+			if err := database.DumpSchema(cmd.Context(), db, databaseType, f); err != nil {
+				cmd.PrintErrf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("Wrote the %s schema to %s\n", databaseType, file)
+		},
+	}
+	dumpCmd.Flags().StringVar(&dbType, "type", "central", "Database type (central or tenant)")
+	dumpCmd.Flags().StringVar(&dbPath, "db", filepath.Join(".", "data", "tracks.sqlite"), "Database path")
+	dumpCmd.Flags().StringVar(&file, "file", "", "Schema file (default migrations/<type>_schema.sql)")
+	return dumpCmd
+}
+
+// SchemaLoadCmd returns a cobra.Command for the db schema:load command
+func SchemaLoadCmd() *cobra.Command {
+	var dbType string
+	var dbPath string
+	var file string
+	loadCmd := &cobra.Command{
+		Use:   "schema:load",
+		Short: "Create the database schema from a file",
+		Long: `Create the schema written by schema:dump in an empty database.
+
+The applied migration versions are restored as well, so newer migrations can be applied on top.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			databaseType, ok := parseType(cmd, dbType)
+			if !ok {
+				return
+			}
+			if file == "" {
+				file = schemaFile(databaseType)
+			}
+
+			f, err := os.Open(file)
+			if err != nil {
+				cmd.PrintErrf("Error: %v\n", err)
+				return
+			}
+			defer f.Close()
+
+			db, err := sql.Open("sqlite3", dbPath)
+			if err != nil {
+				cmd.PrintErrf("Error: failed to connect to database: %v\n", err)
+				return
+			}
+			defer db.Close()
+
+			if err := database.LoadSchema(cmd.Context(), db, f); err != nil {
+				cmd.PrintErrf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("Loaded the %s schema from %s\n", databaseType, file)
+		},
+	}
+	loadCmd.Flags().StringVar(&dbType, "type", "central", "Database type (central or tenant)")
+	loadCmd.Flags().StringVar(&dbPath, "db", filepath.Join(".", "data", "tracks.sqlite"), "Database path")
+	loadCmd.Flags().StringVar(&file, "file", "", "Schema file (default migrations/<type>_schema.sql)")
+	return loadCmd
+}
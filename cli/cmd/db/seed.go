@@ -0,0 +1,62 @@
+package db
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tmeire/tracks/database"
+)
+
+// SeedCmd returns a cobra.Command for the db seed command
+func SeedCmd() *cobra.Command {
+	var dbType string
+	var dbPath string
+	seedCmd := &cobra.Command{
+		Use:   "seed [names...]",
+		Short: "Fill the database with seed data",
+		Long: `Fill the database with the fixtures in fixtures/<type> and the seeds registered with
+database.RegisterSeed, or only the seeds with the given names.
+
+The seeds are part of the application, so this command runs the application in the current
+directory with "go run ." to seed the database instead of starting the server, which requires the
+main function of the application to run the router with tracks.Main. The central
+database from the application config is used, unless --db is set. Tenant databases always
+require --db.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			databaseType, ok := parseType(cmd, dbType)
+			if !ok {
+				return
+			}
+			if databaseType == database.TenantDatabase && dbPath == "" {
+				cmd.PrintErrln("The --db flag is required to seed a tenant database.")
+				return
+			}
+			if dbPath != "" {
+				abs, err := filepath.Abs(dbPath)
+				if err != nil {
+					cmd.PrintErrf("Error: %v\n", err)
+					return
+				}
+				dbPath = abs
+			}
+
+			run := exec.CommandContext(cmd.Context(), "go", "run", ".")
+			run.Stdout = os.Stdout
+			run.Stderr = os.Stderr
+			run.Env = append(os.Environ(),
+				"TRACKS_SEED="+string(databaseType),
+				"TRACKS_SEED_DB="+dbPath,
+				"TRACKS_SEED_NAMES="+strings.Join(args, ","),
+			)
+			if err := run.Run(); err != nil {
+				cmd.PrintErrf("Error: seeding failed: %v\n", err)
+			}
+		},
+	}
+	seedCmd.Flags().StringVar(&dbType, "type", "central", "Database type (central or tenant)")
+	seedCmd.Flags().StringVar(&dbPath, "db", "", "Database path (default: the database of the application)")
+	return seedCmd
+}
@@ -0,0 +1,19 @@
+package db
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tmeire/tracks/database"
+)
+
+// parseType converts the --type flag to a database type, printing an error when it's invalid
+func parseType(cmd *cobra.Command, dbType string) (database.Type, bool) {
+	switch dbType {
+	case "central":
+		return database.CentralDatabase, true
+	case "tenant":
+		return database.TenantDatabase, true
+	default:
+		cmd.PrintErrf("Invalid database type: %s. Must be 'central' or 'tenant'.\n", dbType)
+		return "", false
+	}
+}
@@ -10,12 +10,13 @@ func GenerateCmd() *cobra.Command {
 	generateCmd := &cobra.Command{
 		Use:   "generate",
 		Short: "Generate code for the application",
-		Long:  `Generate code for the application, including controllers, actions, resources, and views.`,
+		Long:  `Generate code for the application, including controllers, actions, resources, views, and migrations.`,
 	}
 
 	// Add subcommands
 	generateCmd.AddCommand(generate.ControllerCmd())
 	generateCmd.AddCommand(generate.ResourceCmd())
+	generateCmd.AddCommand(generate.MigrationCmd())
 
 	return generateCmd
 }
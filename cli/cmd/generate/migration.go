@@ -0,0 +1,40 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tmeire/tracks/cli/project"
+)
+
+// MigrationCmd returns a cobra.Command for the generate migration command
+func MigrationCmd() *cobra.Command {
+	var dbType string
+	cmd := &cobra.Command{
+		Use:   "migration [name]",
+		Short: "Generate a migration",
+		Long: `Generate an empty goose SQL migration.
+
+The migration is created in migrations/central or migrations/tenant, depending on the --type flag,
+and its file name starts with the current timestamp.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			p, err := project.Load()
+			if err != nil {
+				fmt.Printf("Error loading project: %v\n", err)
+				os.Exit(1)
+			}
+
+			_, err = p.AddMigration(dbType, args[0], time.Now())
+			if err != nil {
+				fmt.Printf("Error creating migration: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&dbType, "type", "central", "Database type (central or tenant)")
+
+	return cmd
+}
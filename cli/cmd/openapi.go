@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// OpenAPICmd returns a cobra.Command for the openapi command
+func OpenAPICmd() *cobra.Command {
+	var out string
+	openAPICmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Write the OpenAPI document of the application",
+		Long: `Write the OpenAPI 3.1 document of the routes of the application to a file.
+
+The routes are registered by the application, so this command runs the application in the current
+directory with "go run ." to generate the document instead of starting the server.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			file, err := filepath.Abs(out)
+			if err != nil {
+				cmd.PrintErrf("Error: %v\n", err)
+				return
+			}
+
+			run := exec.CommandContext(cmd.Context(), "go", "run", ".")
+			run.Stdout = os.Stderr
+			run.Stderr = os.Stderr
+			run.Env = append(os.Environ(), "TRACKS_OPENAPI="+file)
+			if err := run.Run(); err != nil {
+				cmd.PrintErrf("Error: failed to run the application: %v\n", err)
+				return
+			}
+
+			cmd.Printf("Wrote the OpenAPI document to %s\n", out)
+		},
+	}
+	openAPICmd.Flags().StringVarP(&out, "out", "o", "openapi.json", "File to write the OpenAPI document to")
+	return openAPICmd
+}
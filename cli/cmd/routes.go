@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/tmeire/tracks"
+)
+
+// RoutesCmd returns a cobra.Command for the routes command
+func RoutesCmd() *cobra.Command {
+	var path string
+	var controller string
+	var verbose bool
+	routesCmd := &cobra.Command{
+		Use:   "routes",
+		Short: "List the routes of the application",
+		Long: `List the routes of the application, including the routes of API versions and tenant routers.
+
+The routes are registered by the application, so this command runs the application in the current
+directory with "go run ." to list its routes instead of starting the server.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, err := os.MkdirTemp("", "tracks-routes")
+			if err != nil {
+				cmd.PrintErrf("Error: %v\n", err)
+				return
+			}
+			defer os.RemoveAll(dir)
+			file := filepath.Join(dir, "routes.json")
+
+			run := exec.CommandContext(cmd.Context(), "go", "run", ".")
+			run.Stdout = os.Stderr
+			run.Stderr = os.Stderr
+			run.Env = append(os.Environ(), "TRACKS_ROUTES="+file)
+			if err := run.Run(); err != nil {
+				cmd.PrintErrf("Error: failed to run the application: %v\n", err)
+				return
+			}
+
+			b, err := os.ReadFile(file)
+			if err != nil {
+				cmd.PrintErrf("Error: the application didn't write its routes: %v\n", err)
+				return
+			}
+			var routes []tracks.Route
+			if err := json.Unmarshal(b, &routes); err != nil {
+				cmd.PrintErrf("Error: %v\n", err)
+				return
+			}
+
+			printRoutes(cmd.OutOrStdout(), filterRoutes(routes, path, controller), verbose)
+		},
+	}
+	routesCmd.Flags().StringVar(&path, "path", "", "Only show the routes whose path starts with this prefix")
+	routesCmd.Flags().StringVar(&controller, "controller", "", "Only show the routes of this controller")
+	routesCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Also show the layout, middlewares and request and response types of the routes")
+	return routesCmd
+}
+
+func filterRoutes(routes []tracks.Route, path, controller string) []tracks.Route {
+	var res []tracks.Route
+	for _, r := range routes {
+		if path != "" && !strings.HasPrefix(r.Path, path) {
+			continue
+		}
+		if controller != "" && r.Controller != controller {
+			continue
+		}
+		res = append(res, r)
+	}
+	return res
+}
+
+func printRoutes(out io.Writer, routes []tracks.Route, verbose bool) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	header := "NAME\tMETHOD\tPATH\tACTION\tROUTER\tVERSION"
+	if verbose {
+		header += "\tLAYOUT\tMIDDLEWARES\tREQUEST\tRESPONSE"
+	}
+	fmt.Fprintln(w, header)
+
+	for _, r := range routes {
+		line := fmt.Sprintf("%s\t%s\t%s\t%s#%s\t%s\t%s", r.Name, r.Method, r.Path, r.Controller, r.Action, r.Router, r.Version)
+		if verbose {
+			line += fmt.Sprintf("\t%s\t%s\t%s\t%s", r.Layout, strings.Join(r.Middlewares, ", "), r.Request, r.Response)
+		}
+		fmt.Fprintln(w, line)
+	}
+	w.Flush()
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/tmeire/tracks/cli/cmd"
+	tenant "github.com/tmeire/tracks/modules/multitenancy/cli"
 )
 
 func main() {
@@ -20,7 +21,10 @@ This CLI provides various utilities and commands for managing the Tracks-based s
 	rootCmd.AddCommand(cmd.AssetsCmd())
 	rootCmd.AddCommand(cmd.GenerateCmd())
 	rootCmd.AddCommand(cmd.DbCmd())
+	rootCmd.AddCommand(cmd.RoutesCmd())
+	rootCmd.AddCommand(cmd.OpenAPICmd())
 	rootCmd.AddCommand(cmd.InitCmd())
+	rootCmd.AddCommand(tenant.TenantCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
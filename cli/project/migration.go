@@ -0,0 +1,36 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+func (p *Project) migrations(dbType string) string {
+	return filepath.Join(p.rootDir, "migrations", dbType)
+}
+
+// AddMigration creates an empty goose SQL migration for the central or tenant database. The file
+// name starts with the current UTC timestamp, so migrations are applied in creation order.
+func (p *Project) AddMigration(dbType, name string, now time.Time) (string, error) {
+	if dbType != "central" && dbType != "tenant" {
+		return "", fmt.Errorf("invalid database type: %s. Must be 'central' or 'tenant'", dbType)
+	}
+
+	name = strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", fmt.Errorf("invalid migration name")
+	}
+
+	filePath := filepath.Join(p.migrations(dbType), now.UTC().Format("20060102150405")+"_"+name+".sql")
+	return filePath, p.createFile(
+		filePath,
+		"templates/migration/migration.sql.tmpl",
+		map[string]string{
+			"Name": name,
+		})
+}
@@ -22,11 +22,29 @@ type Config struct {
 	Cache       CacheConfig                `json:"cache"`
 	Jobs        JobsConfig                 `json:"jobs"`
 	Secrets     SecretsConfig              `json:"secrets"`
+	Queries     QueriesConfig              `json:"queries"`
+	OpenAPI     OpenAPIConfig              `json:"openapi"`
+	Security    SecurityConfig             `json:"security"`
 	Modules     map[string]json.RawMessage `json:"modules"`
 }
 
 type SecretsConfig struct {
 	Signup string `json:"signup"`
+	// Cursor is the key used to sign pagination cursors
+	Cursor string `json:"cursor"`
+	// Encryption holds the keys used to encrypt model attributes. The first key encrypts new
+	// values, the others are only used to decrypt values until they are re-encrypted.
+	Encryption []string `json:"encryption"`
+}
+
+// QueriesConfig configures the query checks that run on every request in development mode
+type QueriesConfig struct {
+	// SlowThreshold is the duration after which a query is reported as slow, e.g. "200ms"
+	SlowThreshold string `json:"slow_threshold"`
+	// RepeatThreshold is the number of identical reads in one request that is reported as an N+1 query
+	RepeatThreshold int `json:"repeat_threshold"`
+	// Strict fails the request on a violation instead of logging a warning
+	Strict bool `json:"strict"`
 }
 
 type CacheConfig struct {
@@ -38,7 +56,9 @@ type JobsConfig struct {
 	Workers int    `json:"workers"`
 }
 
-func configFileName() (string, error) {
+// ConfigFileName returns the path of the application config: the TRACKS_CONFIG_FILE environment
+// variable, or config/config.json in the working directory
+func ConfigFileName() (string, error) {
 	if f := os.Getenv("TRACKS_CONFIG_FILE"); f != "" {
 		return f, nil
 	}
@@ -51,7 +71,7 @@ func configFileName() (string, error) {
 }
 
 func loadConfig() (config Config, err error) {
-	fn, err := configFileName()
+	fn, err := ConfigFileName()
 	if err != nil {
 		return
 	}
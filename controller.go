@@ -113,3 +113,26 @@ func ToPage[T any](data []T, totalCount int, p *Pagination) *Page[T] {
 		LastItem:    p.Offset + len(data),
 	}
 }
+
+// CursorPagination holds the parameters for keyset pagination with database.QueryBuilder.Paginate
+type CursorPagination struct {
+	Cursor string
+	Size   int
+}
+
+func ParseCursorPagination(r *http.Request) *CursorPagination {
+	p := CursorPagination{
+		Cursor: r.URL.Query().Get("cursor"),
+		Size:   10,
+	}
+
+	itemsPerPageStr := r.URL.Query().Get("items_per_page")
+	if itemsPerPageStr != "" {
+		itemsPerPageNum, err := strconv.Atoi(itemsPerPageStr)
+		if err == nil && itemsPerPageNum > 0 {
+			p.Size = itemsPerPageNum
+		}
+	}
+
+	return &p
+}
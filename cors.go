@@ -0,0 +1,218 @@
+package tracks
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CORSConfig configures which cross-origin requests the browser allows
+type CORSConfig struct {
+	// AllowedOrigins are the origins that can make requests, like https://app.example.com. A * in an
+	// origin matches any subdomain, like https://*.example.com for the tenants, and * alone matches
+	// any origin. The base domain and its subdomains are allowed by default.
+	AllowedOrigins []string
+	// AllowedMethods are the methods cross-origin requests can use, GET, HEAD, POST, PUT, PATCH and
+	// DELETE by default
+	AllowedMethods []string
+	// AllowedHeaders are the request headers cross-origin requests can send, * allows any header
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers the browser exposes to the client
+	ExposedHeaders []string
+	// AllowCredentials allows requests with cookies and authorization headers
+	AllowCredentials bool
+	// MaxAge is how long the browser can cache the response to a preflight request
+	MaxAge time.Duration
+}
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	defaultCORSHeaders = []string{"Accept", "Authorization", "Content-Type", "X-Requested-With", defaultCSRFHeaderName}
+)
+
+// CORS allows cross-origin requests to the routes of the router. On a group, the policy only
+// applies to the routes under the prefix of the group, and the policy of the most specific group
+// is used. Preflight OPTIONS requests are answered before the route is matched, as the routes are
+// only registered for their own method.
+//
+//	r.Group("/api").CORS(tracks.CORSConfig{
+//		AllowedOrigins:   []string{"https://app.example.com"},
+//		AllowCredentials: true,
+//		MaxAge:           time.Hour,
+//	})
+func (r *router) CORS(config CORSConfig) Router {
+	if len(config.AllowedOrigins) == 0 {
+		scheme := "http://"
+		if r.Secure() {
+			scheme = "https://"
+		}
+		config.AllowedOrigins = []string{scheme + r.baseDomain, scheme + "*." + r.baseDomain}
+	}
+	// Any website could make requests with the cookies of the user
+	if config.AllowCredentials && slices.Contains(config.AllowedOrigins, "*") {
+		return errRouter{fmt.Errorf("cors: credentials can't be allowed for any origin (*)")}
+	}
+	if len(config.AllowedMethods) == 0 {
+		config.AllowedMethods = defaultCORSMethods
+	}
+	if len(config.AllowedHeaders) == 0 {
+		config.AllowedHeaders = defaultCORSHeaders
+	}
+
+	if r.cors == nil {
+		r.cors = &corsPolicies{}
+	}
+	if r.cors.add(r.prefix, config) {
+		r.GlobalMiddleware(r.cors.middleware)
+	}
+	return r
+}
+
+// corsPolicies are the CORS policies of a router and its groups, by path prefix
+type corsPolicies struct {
+	mu         sync.RWMutex
+	policies   []corsPolicy
+	registered bool
+}
+
+type corsPolicy struct {
+	prefix string
+	config CORSConfig
+}
+
+// add adds the policy for the paths under prefix, and returns whether the middleware still has to
+// be registered
+func (c *corsPolicies) add(prefix string, config CORSConfig) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.policies = slices.DeleteFunc(c.policies, func(p corsPolicy) bool { return p.prefix == prefix })
+	c.policies = append(c.policies, corsPolicy{prefix: prefix, config: config})
+
+	// The most specific prefix first
+	slices.SortStableFunc(c.policies, func(a, b corsPolicy) int { return len(b.prefix) - len(a.prefix) })
+
+	if c.registered {
+		return false
+	}
+	c.registered = true
+	return true
+}
+
+// policy returns the policy for a path
+func (c *corsPolicies) policy(path string) (CORSConfig, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, p := range c.policies {
+		if path == p.prefix || strings.HasPrefix(path, p.prefix+"/") || p.prefix == "" {
+			return p.config, true
+		}
+	}
+	return CORSConfig{}, false
+}
+
+func (c *corsPolicies) middleware(next http.Handler) (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config, ok := c.policy(r.URL.Path)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if preflight {
+			w.Header().Add("Vary", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers")
+			if !config.preflight(w, r, origin) {
+				http.Error(w, "CORS request not allowed", http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if origin != "" && config.allowOrigin(origin) {
+			config.allowHeaders(w, origin)
+			if len(config.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+			}
+		}
+		next.ServeHTTP(w, r)
+	}), nil
+}
+
+// preflight sets the headers of the response to a preflight request, and returns whether the
+// request is allowed
+func (c CORSConfig) preflight(w http.ResponseWriter, r *http.Request, origin string) bool {
+	if origin == "" || !c.allowOrigin(origin) {
+		return false
+	}
+
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	if !slices.Contains(c.AllowedMethods, method) {
+		return false
+	}
+
+	headers := r.Header.Get("Access-Control-Request-Headers")
+	if !slices.Contains(c.AllowedHeaders, "*") {
+		for _, h := range strings.Split(headers, ",") {
+			h = strings.TrimSpace(h)
+			if h != "" && !slices.ContainsFunc(c.AllowedHeaders, func(a string) bool { return strings.EqualFold(a, h) }) {
+				return false
+			}
+		}
+		headers = strings.Join(c.AllowedHeaders, ", ")
+	}
+
+	c.allowHeaders(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+	if headers != "" {
+		w.Header().Set("Access-Control-Allow-Headers", headers)
+	}
+	if c.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+	}
+	return true
+}
+
+// allowHeaders sets the headers that allow the origin to read the response
+func (c CORSConfig) allowHeaders(w http.ResponseWriter, origin string) {
+	// CORS rejects credentials for *, so the origin is never echoed for it
+	if slices.Contains(c.AllowedOrigins, "*") {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowOrigin reports whether the origin matches one of the allowed origins
+func (c CORSConfig) allowOrigin(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+
+		prefix, suffix, wildcard := strings.Cut(allowed, "*")
+		if !wildcard || len(origin) <= len(prefix)+len(suffix) {
+			continue
+		}
+		if strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			// The wildcard only matches subdomains, not a path or a port
+			sub := origin[len(prefix) : len(origin)-len(suffix)]
+			if !strings.ContainsAny(sub, "/:@") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package tracks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS(t *testing.T) {
+	noop := func(r *http.Request) (any, error) { return "ok", nil }
+
+	r := New(t.Context()).
+		CORS(CORSConfig{}).
+		GetFunc("/pages", "pages", "index", noop)
+	r.Group("/api").
+		CORS(CORSConfig{
+			AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+			AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+			ExposedHeaders:   []string{"X-Total-Count"},
+			AllowCredentials: true,
+			MaxAge:           time.Hour,
+		}).
+		PostFunc("/users", "users", "create", noop)
+
+	h, err := r.Handler()
+	require.NoError(t, err)
+
+	serve := func(method, path, origin string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Accept", "application/json")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	preflight := func(path, origin, method, headers string) *httptest.ResponseRecorder {
+		return serve(http.MethodOptions, path, origin, map[string]string{
+			"Access-Control-Request-Method":  method,
+			"Access-Control-Request-Headers": headers,
+		})
+	}
+
+	t.Run("Answers preflight requests", func(t *testing.T) {
+		rr := preflight("/api/users", "https://app.example.com", http.MethodPost, "content-type")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "GET, POST", rr.Header().Get("Access-Control-Allow-Methods"))
+		assert.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), "Content-Type")
+		assert.Equal(t, "3600", rr.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("Matches subdomain wildcards", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, preflight("/api/users", "https://acme.example.org", http.MethodPost, "").Code)
+		assert.Equal(t, http.StatusForbidden, preflight("/api/users", "https://example.org", http.MethodPost, "").Code)
+		assert.Equal(t, http.StatusForbidden, preflight("/api/users", "https://evil.com/.example.org", http.MethodPost, "").Code)
+		assert.Equal(t, http.StatusForbidden, preflight("/api/users", "http://acme.example.org", http.MethodPost, "").Code)
+	})
+
+	t.Run("Rejects preflights that aren't allowed", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, preflight("/api/users", "https://evil.com", http.MethodPost, "").Code)
+		assert.Equal(t, http.StatusForbidden, preflight("/api/users", "https://app.example.com", http.MethodDelete, "").Code)
+		assert.Equal(t, http.StatusForbidden, preflight("/api/users", "https://app.example.com", http.MethodPost, "X-Custom").Code)
+	})
+
+	t.Run("Sets the headers of cross-origin requests", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/api/users", "https://app.example.com", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "X-Total-Count", rr.Header().Get("Access-Control-Expose-Headers"))
+		assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+
+		rr = serve(http.MethodPost, "/api/users", "https://evil.com", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Allows the base domain and tenants by default", func(t *testing.T) {
+		rr := preflight("/pages", "http://acme.tracks.local:8080", http.MethodGet, "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "http://acme.tracks.local:8080", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+
+		assert.Equal(t, http.StatusForbidden, preflight("/pages", "https://app.example.com", http.MethodGet, "").Code)
+	})
+
+	t.Run("Leaves other OPTIONS requests to the routes", func(t *testing.T) {
+		assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodOptions, "/pages", "", nil).Code)
+	})
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	c := CORSConfig{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}, AllowedMethods: defaultCORSMethods}
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	req.Header.Set("Access-Control-Request-Headers", "X-Anything")
+	rr := httptest.NewRecorder()
+	require.True(t, c.preflight(rr, req, "https://any.where"))
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Anything", rr.Header().Get("Access-Control-Allow-Headers"))
+
+	// Credentials can't be combined with *
+	c.AllowCredentials = true
+	rr = httptest.NewRecorder()
+	require.True(t, c.preflight(rr, req, "https://evil.example"))
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+
+	r := New(t.Context()).CORS(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	_, err := r.Handler()
+	assert.Error(t, err)
+}
+
+func TestCORSRegistersOnce(t *testing.T) {
+	r := New(t.Context())
+	for range 3 {
+		r.CORS(CORSConfig{AllowedOrigins: []string{"https://app.example.com"}})
+	}
+	h, err := r.GetFunc("/", "pages", "home", func(r *http.Request) (any, error) { return "ok", nil }).Handler()
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Origin", "https://app.example.com")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	assert.Equal(t, []string{"Origin"}, rr.Header().Values("Vary"))
+}
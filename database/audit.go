@@ -0,0 +1,474 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// Audit actions
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+)
+
+var ErrAuditNotFound = errors.New("audit entry not found")
+
+// Audited is the interface that models implement to record every change made through
+// Repository.Create, Update and Delete in the audit log of their database. The bulk operations
+// CreateMany, Upsert, UpdateWhere and DeleteWhere record an entry for each record they change.
+type Audited interface {
+	// AuditExclude returns the columns that are never written to the audit log, e.g. password hashes
+	AuditExclude() []string
+}
+
+// AuditedModel is a helper struct that models can embed to implement Audited, recording all columns.
+type AuditedModel struct{}
+
+func (AuditedModel) AuditExclude() []string {
+	return nil
+}
+
+// AuditActor identifies who made a change
+type AuditActor struct {
+	UserID    string
+	Tenant    string
+	RequestID string
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor returns a new context in which changes to audited models are attributed to the
+// actor returned by fn. It's called for every change, so a login during the request is taken into
+// account. When the actor has no tenant, the domain of the context is used.
+func WithAuditActor(ctx context.Context, fn func(ctx context.Context) AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, fn)
+}
+
+func auditActor(ctx context.Context) AuditActor {
+	var actor AuditActor
+	if fn, ok := ctx.Value(auditActorKey{}).(func(ctx context.Context) AuditActor); ok {
+		actor = fn(ctx)
+	}
+	if actor.Tenant == "" {
+		actor.Tenant = DomainFromContext(ctx)
+	}
+	return actor
+}
+
+// AuditChange holds the old and new value of a changed column
+type AuditChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// AuditEntry is a single change of a record in the audit log
+type AuditEntry struct {
+	ID        int64
+	Table     string
+	RecordID  string
+	Action    string
+	Changes   map[string]AuditChange
+	UserID    string
+	Tenant    string
+	RequestID string
+	CreatedAt time.Time
+
+	// snapshot holds all audited columns of the record after the change, or before a delete
+	snapshot map[string]json.RawMessage
+}
+
+// auditDomain returns the domain of a domain scoped model, which scopes its audit log like the
+// model itself
+func auditDomain(model any) string {
+	if ds, ok := model.(DomainScoped); ok {
+		return ds.GetDomain()
+	}
+	return ""
+}
+
+// auditValues returns the JSON encoded values of the audited columns of a model
+func auditValues[S Schema, T Model[S, T]](model T) (map[string]json.RawMessage, error) {
+	exclude := any(model).(Audited).AuditExclude()
+
+	res := make(map[string]json.RawMessage)
+	values := model.Values()
+	for i, field := range model.Fields() {
+		if slices.Contains(exclude, field) {
+			continue
+		}
+		value := values[i]
+		if e, ok := value.(encryptedValue); ok {
+			// Encrypted attributes are never written to the audit log in plain text
+			value = e.fingerprint()
+		}
+		v, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s for the audit log: %w", field, err)
+		}
+		res[field] = v
+	}
+	return res, nil
+}
+
+// writeAudit records the difference between the old and new values in the audit log. Nothing
+// is written when no audited column changed.
+func writeAudit(ctx context.Context, table string, id any, domain, action string, old, new map[string]json.RawMessage) error {
+	type change struct {
+		Old json.RawMessage `json:"old"`
+		New json.RawMessage `json:"new"`
+	}
+
+	changes := make(map[string]change)
+	for field, v := range new {
+		if o, ok := old[field]; !ok || string(o) != string(v) {
+			changes[field] = change{Old: old[field], New: v}
+		}
+	}
+	for field, o := range old {
+		if _, ok := new[field]; !ok {
+			changes[field] = change{Old: o}
+		}
+	}
+	if len(changes) == 0 && action == AuditUpdate {
+		return nil
+	}
+
+	snapshot := new
+	if action == AuditDelete {
+		snapshot = old
+	}
+
+	c, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	s, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	actor := auditActor(ctx)
+	_, err = FromContext(ctx).ExecContext(ctx,
+		"INSERT INTO audits (table_name, record_id, domain, action, changes, snapshot, user_id, tenant, request_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		table, fmt.Sprint(id), domain, action, string(c), string(s), actor.UserID, actor.Tenant, actor.RequestID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to write the audit log: %w", err)
+	}
+	return nil
+}
+
+// auditBulk records the changes of a bulk operation in the audit log. The records before and
+// after the change are matched by id: before is empty for inserts and after is empty for deletes.
+func (r *Repository[S, T]) auditBulk(ctx context.Context, action string, before, after []T) error {
+	var ids []string
+	domains := make(map[string]string)
+	old := make(map[string]map[string]json.RawMessage)
+	values := make(map[string]map[string]json.RawMessage)
+
+	for _, record := range before {
+		id := fmt.Sprint(record.GetID())
+		v, err := auditValues(record)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		domains[id] = auditDomain(record)
+		old[id] = v
+	}
+	for _, record := range after {
+		id := fmt.Sprint(record.GetID())
+		v, err := auditValues(record)
+		if err != nil {
+			return err
+		}
+		if _, ok := old[id]; !ok {
+			ids = append(ids, id)
+			domains[id] = auditDomain(record)
+		}
+		values[id] = v
+	}
+
+	for _, id := range ids {
+		if action == AuditUpdate && values[id] == nil {
+			// The record is no longer visible after the update, e.g. because it moved to another domain
+			continue
+		}
+		if err := writeAudit(ctx, r.zero.TableName(), id, domains[id], action, old[id], values[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// found returns whether a model returned by First holds a record
+func found[T any](model T) bool {
+	return !reflect.ValueOf(&model).Elem().IsZero()
+}
+
+// createAudited inserts a new record and records it in the audit log, in a single transaction
+func (r *Repository[S, T]) createAudited(ctx context.Context, model T) (T, error) {
+	var created T
+	err := WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = r.create(ctx, model)
+		if err != nil {
+			return err
+		}
+
+		values, err := auditValues(created)
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, created.TableName(), created.GetID(), auditDomain(created), AuditCreate, nil, values)
+	})
+	if err != nil {
+		return r.zero, err
+	}
+	return created, nil
+}
+
+// updateAudited updates a record and records the changed columns in the audit log, in a single transaction
+func (r *Repository[S, T]) updateAudited(ctx context.Context, model T, action string) error {
+	return WithTransaction(ctx, func(ctx context.Context) error {
+		current, err := r.FindByID(WithDeleted(ctx), model.GetID())
+		if err != nil {
+			return err
+		}
+
+		if err := r.update(ctx, model); err != nil {
+			return err
+		}
+		if !found(current) {
+			return nil
+		}
+
+		old, err := auditValues(current)
+		if err != nil {
+			return err
+		}
+		values, err := auditValues(model)
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, model.TableName(), model.GetID(), auditDomain(current), action, old, values)
+	})
+}
+
+// deleteAudited deletes a record and records its last values in the audit log, in a single transaction
+func (r *Repository[S, T]) deleteAudited(ctx context.Context, model T) error {
+	return WithTransaction(ctx, func(ctx context.Context) error {
+		current, err := r.FindByID(WithDeleted(ctx), model.GetID())
+		if err != nil {
+			return err
+		}
+
+		if err := r.delete(ctx, model); err != nil {
+			return err
+		}
+		if !found(current) {
+			return nil
+		}
+
+		old, err := auditValues(current)
+		if err != nil {
+			return err
+		}
+
+		// Soft-deleted records still exist, only their deleted_at column changed
+		var values map[string]json.RawMessage
+		if _, ok := any(model).(SoftDeletable); ok {
+			deleted, err := r.FindByID(WithDeleted(ctx), model.GetID())
+			if err != nil {
+				return err
+			}
+			if values, err = auditValues(deleted); err != nil {
+				return err
+			}
+		}
+		return writeAudit(ctx, model.TableName(), model.GetID(), auditDomain(current), AuditDelete, old, values)
+	})
+}
+
+// History returns the audit log of the record with the given id, oldest change first
+func (r *Repository[S, T]) History(ctx context.Context, id any) ([]AuditEntry, error) {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "repository.history")
+	defer span.End()
+
+	return r.audits(ctx, "record_id = ?", fmt.Sprint(id))
+}
+
+// audits reads the audit entries of the repository table that match the condition. Like the
+// queries of the repository, the entries of domain scoped models are limited to the domain of the
+// context.
+func (r *Repository[S, T]) audits(ctx context.Context, condition string, args ...any) ([]AuditEntry, error) {
+	args = append([]any{r.zero.TableName()}, args...)
+
+	// Domain-aware scoping
+	if IsDomainFilteringEnabled(ctx) && !shouldSkipDomainScope(ctx) {
+		if _, ok := any(r.zero).(DomainScoped); ok {
+			if domain := DomainFromContext(ctx); domain != "" {
+				condition += " AND domain = ?"
+				args = append(args, domain)
+			}
+		}
+	}
+
+	rows, err := FromContext(ctx).QueryContext(ctx,
+		"SELECT id, table_name, record_id, action, changes, snapshot, user_id, tenant, request_id, created_at FROM audits WHERE table_name = ? AND "+condition+" ORDER BY id",
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var changes, snapshot string
+		err := rows.Scan(&e.ID, &e.Table, &e.RecordID, &e.Action, &changes, &snapshot, &e.UserID, &e.Tenant, &e.RequestID, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(changes), &e.Changes); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(snapshot), &e.snapshot); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Restore returns a record to the state it had right after the change of the audit entry, or
+// right before it when the entry is a delete. Deleted records are created again with their
+// original id. Columns excluded from the audit log keep their current value.
+func (r *Repository[S, T]) Restore(ctx context.Context, auditID int64) (T, error) {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "repository.restore")
+	defer span.End()
+
+	if _, ok := any(r.zero).(Audited); !ok {
+		return r.zero, fmt.Errorf("%s is not audited", r.zero.TableName())
+	}
+
+	entries, err := r.audits(ctx, "id = ?", auditID)
+	if err != nil {
+		return r.zero, err
+	}
+	if len(entries) == 0 {
+		return r.zero, ErrAuditNotFound
+	}
+	entry := entries[0]
+
+	var restored T
+	err = WithTransaction(ctx, func(ctx context.Context) error {
+		current, err := r.FindByID(WithDeleted(ctx), entry.RecordID)
+		if err != nil {
+			return err
+		}
+
+		if found(current) {
+			if current, err = restoreSnapshot(current, entry.snapshot); err != nil {
+				return err
+			}
+			restored = current
+			return r.updateAudited(ctx, current, AuditRestore)
+		}
+
+		var model T
+		rv := reflect.ValueOf(&model).Elem()
+		if rv.Kind() == reflect.Ptr {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		if model, err = restoreSnapshot(model, entry.snapshot); err != nil {
+			return err
+		}
+		field, ok := columnField(reflect.Indirect(rv), "id")
+		if !ok {
+			return fmt.Errorf("no id field found on %s", model.TableName())
+		}
+		if _, err := fmt.Sscan(entry.RecordID, field.Addr().Interface()); err != nil {
+			return fmt.Errorf("invalid record id %s: %w", entry.RecordID, err)
+		}
+
+		restored, err = r.recreate(ctx, model)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		return r.zero, err
+	}
+	return restored, nil
+}
+
+// restoreSnapshot sets the columns of the snapshot on the model and returns it, so models that
+// aren't pointers are restored as well. The version column of versioned models is skipped, so the
+// restore is applied on top of the current version, and encrypted attributes keep their current
+// value.
+func restoreSnapshot[S Schema, T Model[S, T]](model T, snapshot map[string]json.RawMessage) (T, error) {
+	rv := reflect.ValueOf(&model).Elem()
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	_, versioned := any(model).(Versioned)
+	for column, value := range snapshot {
+		if versioned && column == "version" {
+			continue
+		}
+		field, ok := columnField(rv, strings.ReplaceAll(column, "_", ""))
+		if !ok {
+			return model, fmt.Errorf("no field found for column %s of %s", column, model.TableName())
+		}
+		if _, ok := field.Interface().(encryptedValue); ok {
+			// Only a fingerprint of encrypted attributes is recorded
+			continue
+		}
+		if err := json.Unmarshal(value, field.Addr().Interface()); err != nil {
+			return model, fmt.Errorf("invalid value for column %s: %w", column, err)
+		}
+	}
+	return model, nil
+}
+
+// recreate inserts a deleted record again with its original id and records it in the audit log
+func (r *Repository[S, T]) recreate(ctx context.Context, model T) (T, error) {
+	if err := r.prepareCreate(ctx, model); err != nil {
+		return r.zero, err
+	}
+
+	fields := append([]string{"id"}, model.Fields()...)
+	values := append([]any{model.GetID()}, model.Values()...)
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		model.TableName(),
+		strings.Join(fields, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(fields)), ", "))
+
+	if _, err := FromContext(ctx).ExecContext(ctx, query, values...); err != nil {
+		return r.zero, err
+	}
+
+	created, err := r.FindByID(ctx, model.GetID())
+	if err != nil {
+		return r.zero, err
+	}
+
+	snapshot, err := auditValues(created)
+	if err != nil {
+		return r.zero, err
+	}
+	if err := writeAudit(ctx, created.TableName(), created.GetID(), auditDomain(created), AuditRestore, nil, snapshot); err != nil {
+		return r.zero, err
+	}
+	return created, nil
+}
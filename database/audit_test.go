@@ -0,0 +1,295 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Member struct {
+	ID     int
+	Name   string
+	Secret string
+	TimestampsModel
+	AuditedModel
+}
+
+func (a *Member) TableName() string { return "members" }
+func (a *Member) Fields() []string {
+	return []string{"name", "secret", "created_at", "updated_at"}
+}
+func (a *Member) Values() []any {
+	return []any{a.Name, a.Secret, a.CreatedAt, a.UpdatedAt}
+}
+func (a *Member) Scan(ctx context.Context, schema any, row Scanner) (*Member, error) {
+	var res Member
+	err := row.Scan(&res.ID, &res.Name, &res.Secret, &res.CreatedAt, &res.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+func (a *Member) HasAutoIncrementID() bool { return true }
+func (a *Member) GetID() any               { return a.ID }
+func (a *Member) AuditExclude() []string   { return []string{"secret"} }
+
+// Page is a domain scoped audited model
+type Page struct {
+	ID    int
+	Title string
+	DomainScopedModel
+	AuditedModel
+}
+
+func (p *Page) TableName() string { return "pages" }
+func (p *Page) Fields() []string  { return []string{"title", "domain"} }
+func (p *Page) Values() []any     { return []any{p.Title, p.Domain} }
+func (p *Page) Scan(ctx context.Context, schema any, row Scanner) (*Page, error) {
+	var res Page
+	if err := row.Scan(&res.ID, &res.Title, &res.Domain); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+func (p *Page) HasAutoIncrementID() bool { return true }
+func (p *Page) GetID() any               { return p.ID }
+
+// Label is an audited model that isn't a pointer
+type Label struct {
+	ID   int
+	Name string
+	AuditedModel
+}
+
+func (l Label) TableName() string { return "labels" }
+func (l Label) Fields() []string  { return []string{"name"} }
+func (l Label) Values() []any     { return []any{l.Name} }
+func (l Label) Scan(ctx context.Context, schema any, row Scanner) (Label, error) {
+	var res Label
+	err := row.Scan(&res.ID, &res.Name)
+	return res, err
+}
+func (l Label) HasAutoIncrementID() bool { return true }
+func (l Label) GetID() any               { return l.ID }
+
+func TestAudit(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE members (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, secret TEXT, created_at TIMESTAMP, updated_at TIMESTAMP)")
+	require.NoError(t, err)
+
+	ctx := WithDB(context.Background(), db)
+	require.NoError(t, migrateFramework(ctx, db, CentralDatabase))
+	ctx = WithDomain(ctx, "acme.example.com")
+	ctx = WithAuditActor(ctx, func(ctx context.Context) AuditActor {
+		return AuditActor{UserID: "user-1", RequestID: "req-1"}
+	})
+	repo := NewRepository[any, *Member](nil)
+
+	member, err := repo.Create(ctx, &Member{Name: "Initial", Secret: "s3cret"})
+	require.NoError(t, err)
+
+	member.Name = "Renamed"
+	require.NoError(t, repo.Update(ctx, member))
+
+	t.Run("History records changes and actor", func(t *testing.T) {
+		history, err := repo.History(ctx, member.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+
+		assert.Equal(t, AuditCreate, history[0].Action)
+		assert.Equal(t, "Initial", history[0].Changes["name"].New)
+		assert.Equal(t, "user-1", history[0].UserID)
+		assert.Equal(t, "acme.example.com", history[0].Tenant)
+		assert.Equal(t, "req-1", history[0].RequestID)
+
+		assert.Equal(t, AuditUpdate, history[1].Action)
+		assert.Equal(t, AuditChange{Old: "Initial", New: "Renamed"}, history[1].Changes["name"])
+	})
+
+	t.Run("Excluded columns are not recorded", func(t *testing.T) {
+		history, err := repo.History(ctx, member.ID)
+		require.NoError(t, err)
+		for _, e := range history {
+			assert.NotContains(t, e.Changes, "secret")
+		}
+	})
+
+	t.Run("Restore a prior version", func(t *testing.T) {
+		history, err := repo.History(ctx, member.ID)
+		require.NoError(t, err)
+
+		restored, err := repo.Restore(ctx, history[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Initial", restored.Name)
+		assert.Equal(t, "s3cret", restored.Secret)
+
+		history, err = repo.History(ctx, member.ID)
+		require.NoError(t, err)
+		assert.Equal(t, AuditRestore, history[len(history)-1].Action)
+	})
+
+	t.Run("Restore a deleted record", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, member))
+
+		deleted, err := repo.FindByID(ctx, member.ID)
+		require.NoError(t, err)
+		assert.Nil(t, deleted)
+
+		history, err := repo.History(ctx, member.ID)
+		require.NoError(t, err)
+		last := history[len(history)-1]
+		assert.Equal(t, AuditDelete, last.Action)
+		assert.Nil(t, last.Changes["name"].New)
+
+		restored, err := repo.Restore(ctx, last.ID)
+		require.NoError(t, err)
+		assert.Equal(t, member.ID, restored.ID)
+		assert.Equal(t, "Initial", restored.Name)
+	})
+
+	t.Run("Restore an unknown entry", func(t *testing.T) {
+		_, err := repo.Restore(ctx, 999)
+		assert.ErrorIs(t, err, ErrAuditNotFound)
+	})
+
+	t.Run("Failed changes are not audited", func(t *testing.T) {
+		before, err := repo.History(ctx, member.ID)
+		require.NoError(t, err)
+
+		_, err = db.Exec("CREATE TRIGGER no_updates BEFORE UPDATE ON members BEGIN SELECT RAISE(ABORT, 'read only'); END")
+		require.NoError(t, err)
+		defer db.Exec("DROP TRIGGER no_updates")
+
+		member.Name = "Blocked"
+		assert.Error(t, repo.Update(ctx, member))
+
+		after, err := repo.History(ctx, member.ID)
+		require.NoError(t, err)
+		assert.Len(t, after, len(before))
+	})
+}
+
+func TestAuditScope(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE pages (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT, domain TEXT)")
+	require.NoError(t, err)
+	ctx := WithDomainFiltering(WithDB(context.Background(), db), true)
+	require.NoError(t, migrateFramework(ctx, db, CentralDatabase))
+
+	repo := NewRepository[any, *Page](nil)
+	page, err := repo.Create(ctx, &Page{Title: "About", DomainScopedModel: DomainScopedModel{Domain: "a.example.com"}})
+	require.NoError(t, err)
+
+	history, err := repo.History(WithDomain(ctx, "a.example.com"), page.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+
+	other := WithDomain(ctx, "b.example.com")
+	history, err = repo.History(other, page.ID)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	// Entries of another domain can't be restored either
+	_, err = repo.Restore(other, 1)
+	assert.ErrorIs(t, err, ErrAuditNotFound)
+}
+
+func TestAuditRestoreValue(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE labels (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)")
+	require.NoError(t, err)
+	ctx := WithDB(context.Background(), db)
+	require.NoError(t, migrateFramework(ctx, db, CentralDatabase))
+
+	repo := NewRepository[any, Label](nil)
+	label, err := repo.Create(ctx, Label{Name: "urgent"})
+	require.NoError(t, err)
+
+	label.Name = "later"
+	require.NoError(t, repo.Update(ctx, label))
+
+	history, err := repo.History(ctx, label.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	restored, err := repo.Restore(ctx, history[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "urgent", restored.Name)
+
+	current, err := repo.FindByID(ctx, label.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "urgent", current.Name)
+}
+
+func TestAuditBulk(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE labels (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE)")
+	require.NoError(t, err)
+	ctx := WithDB(context.Background(), db)
+	require.NoError(t, migrateFramework(ctx, db, CentralDatabase))
+
+	repo := NewRepository[any, Label](nil)
+	labels := []Label{{Name: "urgent"}, {Name: "later"}}
+	require.NoError(t, repo.CreateMany(ctx, labels))
+
+	history, err := repo.History(ctx, labels[0].ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, AuditCreate, history[0].Action)
+	assert.Equal(t, "urgent", history[0].Changes["name"].New)
+
+	upserted, err := repo.Upsert(ctx, Label{Name: "someday"}, []string{"name"}, "name")
+	require.NoError(t, err)
+	_, err = repo.Upsert(ctx, Label{Name: "later"}, []string{"name"}, "name")
+	require.NoError(t, err)
+
+	history, err = repo.History(ctx, upserted.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, AuditCreate, history[0].Action)
+
+	affected, err := repo.Select().Where("name = ?", "urgent").UpdateWhere(ctx, map[string]any{"name": "now"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	history, err = repo.History(ctx, labels[0].ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, AuditUpdate, history[1].Action)
+	assert.Equal(t, AuditChange{Old: "urgent", New: "now"}, history[1].Changes["name"])
+
+	affected, err = repo.Select().DeleteWhere(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+
+	history, err = repo.History(ctx, labels[1].ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2, "an upsert without changes is not recorded")
+	assert.Equal(t, AuditDelete, history[1].Action)
+	assert.Equal(t, "later", history[1].Changes["name"].Old)
+
+	// Deleted records can be restored from the entries of bulk operations
+	restored, err := repo.Restore(ctx, history[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "later", restored.Name)
+}
@@ -0,0 +1,258 @@
+package database
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrCorruptBackup is returned when a backup fails the integrity check before it is restored
+var ErrCorruptBackup = errors.New("backup failed the integrity check")
+
+const backupExt = ".sqlite"
+
+// BackupOptions configures how backups are written
+type BackupOptions struct {
+	// Compress compresses backups with gzip
+	Compress bool
+	// Retain is the number of backups to keep in the backup directory, older backups are removed.
+	// All backups are kept when it's 0.
+	Retain int
+}
+
+// Backup writes a consistent snapshot of db to a new file in dir, using VACUUM INTO, so the
+// database stays available for reads and writes while it's backed up. Backups are named after
+// the time they were made, and the oldest backups beyond the retention are removed. It returns
+// the path of the backup.
+func Backup(ctx context.Context, db Database, dir string, opts ...BackupOptions) (string, error) {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "database.backup", trace.WithAttributes(attribute.String("dir", dir)))
+	defer span.End()
+
+	var opt BackupOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	path := filepath.Join(dir, time.Now().UTC().Format("20060102-150405.000")+backupExt)
+	if _, err := db.ExecContext(UsePrimary(ctx), "VACUUM INTO ?", path); err != nil {
+		span.RecordError(err)
+		return "", fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	if opt.Compress {
+		compressed, err := compressBackup(path)
+		if err != nil {
+			os.Remove(path)
+			span.RecordError(err)
+			return "", err
+		}
+		path = compressed
+	}
+
+	if opt.Retain > 0 {
+		if err := pruneBackups(dir, opt.Retain); err != nil {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+// BackupFile backs up the SQLite database file at dbPath like Backup, through a separate
+// read-only connection.
+func BackupFile(ctx context.Context, dbPath string, dir string, opts ...BackupOptions) (string, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return "", fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", readOnlyURI(dbPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	return Backup(ctx, db, dir, opts...)
+}
+
+// Backups returns the paths of the backups in dir, oldest first
+func Backups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, e := range entries {
+		if !e.IsDir() && (strings.HasSuffix(e.Name(), backupExt) || strings.HasSuffix(e.Name(), backupExt+".gz")) {
+			backups = append(backups, filepath.Join(dir, e.Name()))
+		}
+	}
+	// The names start with the time of the backup, so they sort chronologically
+	slices.Sort(backups)
+	return backups, nil
+}
+
+// FilePath returns the path of the file of db
+func FilePath(ctx context.Context, db Database) (string, error) {
+	var path string
+	err := db.QueryRowContext(UsePrimary(ctx), "SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&path)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the database file: %w", err)
+	}
+	if path == "" {
+		return "", errors.New("database is not stored in a file")
+	}
+	return path, nil
+}
+
+// Restore replaces the database file at dbPath with a backup made by Backup, after checking the
+// integrity of the backup. The database must not be in use while it's restored: stop the
+// application, or close all connections to the database first.
+func Restore(ctx context.Context, backupPath string, dbPath string) error {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "database.restore", trace.WithAttributes(attribute.String("backup", backupPath)))
+	defer span.End()
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return err
+	}
+
+	// Copy the backup next to the database first, so it can be moved in place in one step
+	tmp := dbPath + ".restore"
+	if err := copyBackup(backupPath, tmp); err != nil {
+		os.Remove(tmp)
+		span.RecordError(err)
+		return err
+	}
+
+	if err := checkIntegrity(ctx, tmp); err != nil {
+		os.Remove(tmp)
+		span.RecordError(err)
+		return err
+	}
+
+	// The WAL of the old database must not be applied to the restored one
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return os.Rename(tmp, dbPath)
+}
+
+// compressBackup replaces the backup with a gzip compressed copy
+func compressBackup(path string) (string, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	compressed := path + ".gz"
+	out, err := os.Create(compressed)
+	if err != nil {
+		return "", err
+	}
+
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(compressed)
+		return "", fmt.Errorf("failed to compress backup: %w", err)
+	}
+
+	return compressed, os.Remove(path)
+}
+
+// copyBackup copies a backup to dst, decompressing it when needed
+func copyBackup(backupPath, dst string) error {
+	in, err := os.Open(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer in.Close()
+
+	var r io.Reader = in
+	if strings.HasSuffix(backupPath, ".gz") {
+		zr, err := gzip.NewReader(in)
+		if err != nil {
+			return fmt.Errorf("failed to decompress backup: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+	return out.Close()
+}
+
+// readOnlyURI returns the URI that opens the SQLite database at path read-only. The path is
+// escaped, so characters like ? and # are part of the file name.
+func readOnlyURI(path string) string {
+	u := url.URL{Scheme: "file", OmitHost: true, Path: path, RawQuery: "mode=ro"}
+	return u.String()
+}
+
+func checkIntegrity(ctx context.Context, path string) error {
+	db, err := sql.Open("sqlite3", readOnlyURI(path))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptBackup, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("%w: %s", ErrCorruptBackup, result)
+	}
+	return nil
+}
+
+// pruneBackups removes the oldest backups in dir, keeping the given number of backups
+func pruneBackups(dir string, retain int) error {
+	backups, err := Backups(dir)
+	if err != nil {
+		return err
+	}
+	for len(backups) > retain {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "data", "tracks.sqlite")
+	require.NoError(t, os.MkdirAll(filepath.Dir(dbPath), 0755))
+
+	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO products (name) VALUES ('kept')")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	backups := filepath.Join(dir, "backups", "central")
+
+	t.Run("Backup writes a snapshot", func(t *testing.T) {
+		path, err := Backup(ctx, db, backups)
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(path, ".sqlite"))
+
+		snapshot, err := sql.Open("sqlite3", path)
+		require.NoError(t, err)
+		defer snapshot.Close()
+
+		var name string
+		require.NoError(t, snapshot.QueryRow("SELECT name FROM products").Scan(&name))
+		assert.Equal(t, "kept", name)
+	})
+
+	t.Run("Backup compresses and prunes old backups", func(t *testing.T) {
+		var last string
+		for range 3 {
+			// Backups are named by the millisecond
+			time.Sleep(2 * time.Millisecond)
+			last, err = BackupFile(ctx, dbPath, backups, BackupOptions{Compress: true, Retain: 2})
+			require.NoError(t, err)
+		}
+		assert.True(t, strings.HasSuffix(last, ".sqlite.gz"))
+
+		all, err := Backups(backups)
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		assert.Equal(t, last, all[1])
+	})
+
+	t.Run("Restore replaces the database", func(t *testing.T) {
+		all, err := Backups(backups)
+		require.NoError(t, err)
+
+		_, err = db.Exec("INSERT INTO products (name) VALUES ('lost')")
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+
+		require.NoError(t, Restore(ctx, all[len(all)-1], dbPath))
+
+		restored, err := sql.Open("sqlite3", dbPath)
+		require.NoError(t, err)
+		defer restored.Close()
+
+		var count int
+		require.NoError(t, restored.QueryRow("SELECT COUNT(*) FROM products").Scan(&count))
+		assert.Equal(t, 1, count)
+
+		path, err := FilePath(ctx, restored)
+		require.NoError(t, err)
+		assert.Equal(t, dbPath, path)
+	})
+
+	t.Run("Restore rejects corrupt backups", func(t *testing.T) {
+		corrupt := filepath.Join(dir, "corrupt.sqlite")
+		require.NoError(t, os.WriteFile(corrupt, []byte("not a database"), 0644))
+
+		err := Restore(ctx, corrupt, dbPath)
+		assert.ErrorIs(t, err, ErrCorruptBackup)
+
+		_, err = os.Stat(dbPath + ".restore")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestBackupFileEscapesPath(t *testing.T) {
+	dir := t.TempDir()
+	created := filepath.Join(dir, "tracks.sqlite")
+	db, err := sql.Open("sqlite3", created)
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// A ? or # in the path would otherwise start the query or fragment of the URI
+	dbPath := filepath.Join(dir, "tracks?mode=memory#1.sqlite")
+	require.NoError(t, os.Rename(created, dbPath))
+
+	path, err := BackupFile(context.Background(), dbPath, filepath.Join(dir, "backups"))
+	require.NoError(t, err)
+
+	snapshot, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer snapshot.Close()
+
+	var count int
+	require.NoError(t, snapshot.QueryRow("SELECT COUNT(*) FROM products").Scan(&count))
+
+	require.NoError(t, Restore(context.Background(), path, dbPath))
+}
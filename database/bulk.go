@@ -0,0 +1,548 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxBulkParameters caps the number of bound parameters in a single statement, which keeps
+// batches below the SQLITE_MAX_VARIABLE_NUMBER default of older SQLite versions.
+const maxBulkParameters = 999
+
+// CreateMany inserts the models in batched multi-row INSERT statements, all within a single
+// transaction. Unlike Create, the inserted records are not read back from the database. Instead,
+// the IDs of auto-increment models are set on the given models, before the AfterCreate hooks are
+// called on them. Use SkipHooks to skip the hooks. Audited models are recorded in the audit log.
+func (r *Repository[S, T]) CreateMany(ctx context.Context, models []T) error {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "repository.createmany", trace.WithAttributes(
+		attribute.String("table", r.zero.TableName()),
+		attribute.Int("count", len(models)),
+	))
+	defer span.End()
+	ctx = UsePrimary(ctx)
+
+	if len(models) == 0 {
+		return nil
+	}
+
+	return WithTransaction(ctx, func(ctx context.Context) error {
+		var fields []string
+		var rows [][]any
+		var batch []int
+		batchSize := 1
+
+		flush := func() error {
+			if len(rows) == 0 {
+				return nil
+			}
+			defer func() {
+				rows = rows[:0]
+				batch = batch[:0]
+			}()
+
+			placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(fields)), ", ") + ")"
+			tuples := make([]string, len(rows))
+			args := make([]any, 0, len(rows)*len(fields))
+			for i, values := range rows {
+				tuples[i] = placeholders
+				args = append(args, values...)
+			}
+
+			query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+				r.zero.TableName(),
+				strings.Join(fields, ", "),
+				strings.Join(tuples, ", "))
+
+			if !r.zero.HasAutoIncrementID() {
+				_, err := FromContext(ctx).ExecContext(ctx, query, args...)
+				return err
+			}
+			ids, err := r.insertReturningIDs(ctx, query+" RETURNING id", args)
+			if err != nil {
+				return err
+			}
+			if len(ids) != len(batch) {
+				return fmt.Errorf("inserted %d records, but %d IDs were returned", len(batch), len(ids))
+			}
+			for i, id := range ids {
+				if err := setID(&models[batch[i]], id); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for i, model := range models {
+			if err := r.prepareCreate(ctx, model); err != nil {
+				return err
+			}
+
+			f, values := r.insertColumns(model)
+			if fields == nil {
+				fields = f
+				batchSize = max(1, maxBulkParameters/len(fields))
+			}
+			rows = append(rows, values)
+			batch = append(batch, i)
+
+			if len(rows) >= batchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+
+		if _, ok := any(r.zero).(Audited); ok {
+			if err := r.auditBulk(ctx, AuditCreate, nil, models); err != nil {
+				return err
+			}
+		}
+
+		if !shouldSkipHooks(ctx) {
+			for _, model := range models {
+				if h, ok := any(model).(AfterCreateHook); ok {
+					if err := h.AfterCreate(ctx); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// insertReturningIDs runs a multi-row INSERT statement that returns the auto-increment IDs of the
+// inserted records. The order of RETURNING rows is undefined, but the IDs of a single statement
+// are assigned in increasing order, so the sorted IDs match the order of the rows.
+func (r *Repository[S, T]) insertReturningIDs(ctx context.Context, query string, args []any) ([]int64, error) {
+	rows, err := FromContext(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// setID sets the id field of a model, which may be a pointer to a struct
+func setID[T any](model *T, id int64) error {
+	rv := reflect.Indirect(reflect.ValueOf(model).Elem())
+	field, ok := columnField(rv, "id")
+	if !ok {
+		return fmt.Errorf("no id field found on %s", rv.Type())
+	}
+	if _, err := fmt.Sscan(strconv.FormatInt(id, 10), field.Addr().Interface()); err != nil {
+		return fmt.Errorf("invalid id %d for %s: %w", id, rv.Type(), err)
+	}
+	return nil
+}
+
+// Upsert inserts the model or, when the insert conflicts on the conflictTarget columns, updates
+// the existing record instead. Only the updateColumns are overwritten; when none are given, all
+// fields except the conflict target and created_at are updated. For domain scoped models, a
+// conflicting record of another domain is never updated and ErrDuplicate is returned instead.
+//
+// The BeforeCreate hooks are called first, as they may change the values that conflict. The
+// conflicting record is looked up next, so the BeforeUpdate and AfterUpdate hooks are called
+// around an update and the AfterCreate hooks after an insert, unless the context was created with
+// SkipHooks. For audited models, the insert or update is recorded in the audit log.
+func (r *Repository[S, T]) Upsert(ctx context.Context, model T, conflictTarget []string, updateColumns ...string) (T, error) {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "repository.upsert", trace.WithAttributes(attribute.String("table", r.zero.TableName())))
+	defer span.End()
+	ctx = UsePrimary(ctx)
+
+	if len(conflictTarget) == 0 {
+		return r.zero, errors.New("upsert requires a conflict target")
+	}
+
+	allowedFields := map[string]bool{"id": true}
+	for _, f := range r.zero.Fields() {
+		allowedFields[f] = true
+	}
+	for _, f := range slices.Concat(conflictTarget, updateColumns) {
+		if !allowedFields[f] {
+			return r.zero, fmt.Errorf("invalid field: %s", f)
+		}
+	}
+
+	var upserted T
+	err := WithTransaction(ctx, func(ctx context.Context) error {
+		if err := r.beforeCreate(ctx, model); err != nil {
+			return err
+		}
+		conflictID, err := r.conflicting(ctx, model, conflictTarget)
+		if err != nil {
+			return err
+		}
+		existing := conflictID != nil
+
+		_, audited := any(r.zero).(Audited)
+		var current []T
+		if existing && audited {
+			record, err := r.FindByID(WithDeleted(ctx), conflictID)
+			if err != nil {
+				return err
+			}
+			if found(record) {
+				current = []T{record}
+			}
+		}
+
+		if existing {
+			if h, ok := any(model).(BeforeUpdateHook); ok && !shouldSkipHooks(ctx) {
+				if err := h.BeforeUpdate(ctx); err != nil {
+					return err
+				}
+			}
+		} else if err := r.validateCreate(ctx, model); err != nil {
+			return err
+		}
+		if ts, ok := any(model).(Timestamped); ok {
+			ts.SetUpdatedAt(time.Now())
+		}
+
+		query, values, err := r.upsertQuery(ctx, model, conflictTarget, updateColumns)
+		if err != nil {
+			return err
+		}
+
+		var id any
+		err = FromContext(ctx).QueryRowContext(ctx, query, values...).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDuplicate
+		}
+		if err != nil {
+			return err
+		}
+
+		upserted, err = r.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if audited {
+			action := AuditCreate
+			if existing {
+				action = AuditUpdate
+			}
+			if err := r.auditBulk(ctx, action, current, []T{upserted}); err != nil {
+				return err
+			}
+		}
+
+		if shouldSkipHooks(ctx) {
+			return nil
+		}
+		if h, ok := any(upserted).(AfterUpdateHook); ok && existing {
+			return h.AfterUpdate(ctx)
+		}
+		if h, ok := any(upserted).(AfterCreateHook); ok && !existing {
+			return h.AfterCreate(ctx)
+		}
+		return nil
+	})
+	if err != nil {
+		return r.zero, err
+	}
+	return upserted, nil
+}
+
+// conflicting returns the id of the record with the values of the conflict target columns of the
+// model, regardless of its domain, or nil when no such record exists
+func (r *Repository[S, T]) conflicting(ctx context.Context, model T, conflictTarget []string) (any, error) {
+	fields, values := r.insertColumns(model)
+	fields, values = append([]string{"id"}, fields...), append([]any{model.GetID()}, values...)
+
+	conditions := make([]string, len(conflictTarget))
+	args := make([]any, len(conflictTarget))
+	for i, column := range conflictTarget {
+		conditions[i] = column + " = ?"
+		args[i] = values[slices.Index(fields, column)]
+	}
+
+	var id any
+	query := fmt.Sprintf("SELECT id FROM %s WHERE %s LIMIT 1", r.zero.TableName(), strings.Join(conditions, " AND "))
+	err := FromContext(ctx).QueryRowContext(ctx, query, args...).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return id, err
+}
+
+// upsertQuery builds the INSERT ... ON CONFLICT statement of Upsert, returning the id of the record
+func (r *Repository[S, T]) upsertQuery(ctx context.Context, model T, conflictTarget []string, updateColumns []string) (string, []any, error) {
+	fields, values := r.insertColumns(model)
+
+	_, isVersioned := any(model).(Versioned)
+	if len(updateColumns) == 0 {
+		for _, f := range fields {
+			if f == "id" || f == "created_at" || slices.Contains(conflictTarget, f) {
+				continue
+			}
+			updateColumns = append(updateColumns, f)
+		}
+	}
+
+	var setClause []string
+	for _, f := range updateColumns {
+		if isVersioned && f == "version" {
+			continue
+		}
+		setClause = append(setClause, fmt.Sprintf("%s = excluded.%s", f, f))
+	}
+	if isVersioned {
+		setClause = append(setClause, fmt.Sprintf("version = %s.version + 1", r.zero.TableName()))
+	}
+	if len(setClause) == 0 {
+		return "", nil, errors.New("upsert has no columns to update")
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
+		r.zero.TableName(),
+		strings.Join(fields, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(fields)), ", "),
+		strings.Join(conflictTarget, ", "),
+		strings.Join(setClause, ", "))
+
+	// Domain-aware scoping
+	if IsDomainFilteringEnabled(ctx) && !shouldSkipDomainScope(ctx) {
+		if _, ok := any(model).(DomainScoped); ok && DomainFromContext(ctx) != "" {
+			query += fmt.Sprintf(" WHERE %s.domain = excluded.domain", r.zero.TableName())
+		}
+	}
+
+	return query + " RETURNING id", values, nil
+}
+
+// UpdateWhere sets the given columns on all records that match the query in a single UPDATE
+// statement and returns the number of affected records.
+//
+// When the model implements the update hooks, the matching records are loaded first so the
+// hooks can be called for each of them. Use SkipHooks to avoid loading the records. The records
+// of audited models are always loaded, to record their changes in the audit log.
+func (q *QueryBuilder[S, T]) UpdateWhere(ctx context.Context, values map[string]any) (int64, error) {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "querybuilder.updatewhere", trace.WithAttributes(attribute.String("table", q.tableName)))
+	defer span.End()
+	ctx = UsePrimary(ctx)
+
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	allowedFields := make(map[string]bool)
+	for _, f := range q.repo.zero.Fields() {
+		allowedFields[f] = true
+	}
+
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		if !allowedFields[column] {
+			return 0, fmt.Errorf("invalid field: %s", column)
+		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	var setClause []string
+	var args []any
+	for _, column := range columns {
+		setClause = append(setClause, fmt.Sprintf("%s = ?", column))
+		args = append(args, values[column])
+	}
+	if _, ok := any(q.repo.zero).(Timestamped); ok && values["updated_at"] == nil {
+		setClause = append(setClause, "updated_at = ?")
+		args = append(args, time.Now())
+	}
+	if _, ok := any(q.repo.zero).(Versioned); ok && values["version"] == nil {
+		setClause = append(setClause, "version = version + 1")
+	}
+
+	where, whereArgs := q.where(ctx)
+	query := fmt.Sprintf("UPDATE %s SET %s%s", q.tableName, strings.Join(setClause, ", "), where)
+	args = append(args, whereArgs...)
+
+	_, before := any(q.repo.zero).(BeforeUpdateHook)
+	_, after := any(q.repo.zero).(AfterUpdateHook)
+	_, audited := any(q.repo.zero).(Audited)
+	hooks := !shouldSkipHooks(ctx) && (before || after)
+	if !hooks && !audited {
+		return q.exec(ctx, query, args)
+	}
+
+	var affected int64
+	err := WithTransaction(ctx, func(ctx context.Context) error {
+		records, err := q.Execute(ctx)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]any, len(records))
+		for i, record := range records {
+			ids[i] = record.GetID()
+			if h, ok := any(record).(BeforeUpdateHook); ok && hooks {
+				if err := h.BeforeUpdate(ctx); err != nil {
+					return err
+				}
+			}
+		}
+
+		affected, err = q.exec(ctx, query, args)
+		if err != nil || (!after && !audited) {
+			return err
+		}
+
+		// Reload the records by ID, as the update may have changed whether they match the query
+		updated, err := q.repo.findByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if audited {
+			if err := q.repo.auditBulk(ctx, AuditUpdate, records, updated); err != nil {
+				return err
+			}
+		}
+		if !hooks || !after {
+			return nil
+		}
+		for _, record := range updated {
+			if err := any(record).(AfterUpdateHook).AfterUpdate(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return affected, err
+}
+
+// DeleteWhere removes all records that match the query in a single statement and returns the
+// number of affected records. For soft-deletable models, the deleted_at column is set instead.
+//
+// When the model implements the delete hooks, the matching records are loaded first so the
+// hooks can be called for each of them. Use SkipHooks to avoid loading the records. The records
+// of audited models are always loaded, to record their last values in the audit log.
+func (q *QueryBuilder[S, T]) DeleteWhere(ctx context.Context) (int64, error) {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "querybuilder.deletewhere", trace.WithAttributes(attribute.String("table", q.tableName)))
+	defer span.End()
+	ctx = UsePrimary(ctx)
+
+	where, args := q.where(ctx)
+	query := "DELETE FROM " + q.tableName + where
+
+	_, softDelete := any(q.repo.zero).(SoftDeletable)
+	deletedAt := time.Now()
+	if softDelete {
+		query = "UPDATE " + q.tableName + " SET deleted_at = ?" + where
+		args = append([]any{deletedAt}, args...)
+	}
+
+	_, before := any(q.repo.zero).(BeforeDeleteHook)
+	_, after := any(q.repo.zero).(AfterDeleteHook)
+	_, audited := any(q.repo.zero).(Audited)
+	hooks := !shouldSkipHooks(ctx) && (before || after)
+	if !hooks && !audited {
+		return q.exec(ctx, query, args)
+	}
+
+	var affected int64
+	err := WithTransaction(ctx, func(ctx context.Context) error {
+		records, err := q.Execute(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if h, ok := any(record).(BeforeDeleteHook); ok && hooks {
+				if err := h.BeforeDelete(ctx); err != nil {
+					return err
+				}
+			}
+		}
+
+		affected, err = q.exec(ctx, query, args)
+		if err != nil {
+			return err
+		}
+
+		if audited {
+			// Soft-deleted records still exist, only their deleted_at column changed
+			var deleted []T
+			if softDelete {
+				ids := make([]any, len(records))
+				for i, record := range records {
+					ids[i] = record.GetID()
+				}
+				if deleted, err = q.repo.findByIDs(WithDeleted(ctx), ids); err != nil {
+					return err
+				}
+			}
+			if err := q.repo.auditBulk(ctx, AuditDelete, records, deleted); err != nil {
+				return err
+			}
+		}
+		if !hooks || !after {
+			return nil
+		}
+
+		for _, record := range records {
+			if sd, ok := any(record).(SoftDeletable); ok && softDelete {
+				sd.SetDeletedAt(&deletedAt)
+			}
+			if err := any(record).(AfterDeleteHook).AfterDelete(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return affected, err
+}
+
+// exec runs a statement that modifies records and returns the number of affected rows
+func (q *QueryBuilder[S, T]) exec(ctx context.Context, query string, args []any) (int64, error) {
+	res, err := FromContext(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// findByIDs retrieves the records with the given IDs, in batches that stay below the maximum
+// number of bound parameters.
+func (r *Repository[S, T]) findByIDs(ctx context.Context, ids []any) ([]T, error) {
+	var results []T
+	for chunk := range slices.Chunk(ids, maxBulkParameters-1) {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", ")
+		records, err := r.Select().Where("id IN ("+placeholders+")", chunk...).Execute(ctx)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, records...)
+	}
+	return results, nil
+}
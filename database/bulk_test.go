@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Item struct {
+	ID    int    `json:"id"`
+	SKU   string `json:"sku"`
+	Stock int    `json:"stock"`
+	DomainScopedModel
+}
+
+func (i *Item) TableName() string { return "items" }
+func (i *Item) Fields() []string  { return []string{"sku", "stock", "domain"} }
+func (i *Item) Values() []any     { return []any{i.SKU, i.Stock, i.Domain} }
+func (i *Item) Scan(ctx context.Context, schema any, row Scanner) (*Item, error) {
+	var res Item
+	err := row.Scan(&res.ID, &res.SKU, &res.Stock, &res.Domain)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+func (i *Item) HasAutoIncrementID() bool { return true }
+func (i *Item) GetID() any               { return i.ID }
+
+// Tag lowercases its name before it's created
+type Tag struct {
+	ID      int
+	Name    string
+	Updated bool
+}
+
+func (g *Tag) TableName() string { return "tags" }
+func (g *Tag) Fields() []string  { return []string{"name"} }
+func (g *Tag) Values() []any     { return []any{g.Name} }
+func (g *Tag) Scan(ctx context.Context, schema any, row Scanner) (*Tag, error) {
+	var res Tag
+	err := row.Scan(&res.ID, &res.Name)
+	return &res, err
+}
+func (g *Tag) HasAutoIncrementID() bool { return true }
+func (g *Tag) GetID() any               { return g.ID }
+func (g *Tag) BeforeCreate(ctx context.Context) error {
+	g.Name = strings.ToLower(g.Name)
+	return nil
+}
+func (g *Tag) AfterUpdate(ctx context.Context) error {
+	g.Updated = true
+	return nil
+}
+
+func TestBulkOperations(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY AUTOINCREMENT, sku TEXT, stock INTEGER, domain TEXT, UNIQUE(domain, sku))")
+	require.NoError(t, err)
+
+	ctx := WithDomainFiltering(WithDB(context.Background(), db), true)
+	ctxA := WithDomain(ctx, "a.com")
+	ctxB := WithDomain(ctx, "b.com")
+	repo := NewRepository[any, *Item](nil)
+
+	t.Run("CreateMany", func(t *testing.T) {
+		var items []*Item
+		for i := range 1000 {
+			items = append(items, &Item{SKU: fmt.Sprintf("sku-%d", i), Stock: i})
+		}
+		require.NoError(t, repo.CreateMany(ctxA, items))
+
+		// The IDs are set on the models, across batches
+		for _, i := range []int{0, 500, 999} {
+			found, err := repo.FindByID(ctxA, items[i].ID)
+			require.NoError(t, err)
+			assert.Equal(t, items[i].SKU, found.SKU)
+		}
+
+		count, err := repo.Count(ctxA)
+		require.NoError(t, err)
+		assert.Equal(t, 1000, count)
+
+		count, err = repo.Count(ctxB)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("CreateMany rolls back on error", func(t *testing.T) {
+		err := repo.CreateMany(ctxB, []*Item{{SKU: "dup"}, {SKU: "dup"}})
+		require.Error(t, err)
+
+		count, err := repo.Count(ctxB)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Upsert", func(t *testing.T) {
+		created, err := repo.Upsert(ctxB, &Item{SKU: "upsert", Stock: 1}, []string{"domain", "sku"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, created.Stock)
+		assert.Equal(t, "b.com", created.Domain)
+
+		updated, err := repo.Upsert(ctxB, &Item{SKU: "upsert", Stock: 5}, []string{"domain", "sku"}, "stock")
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, updated.ID)
+		assert.Equal(t, 5, updated.Stock)
+
+		_, err = repo.Upsert(ctxB, &Item{SKU: "upsert"}, []string{"domain", "sku"}, "unknown")
+		assert.Error(t, err)
+	})
+
+	t.Run("UpdateWhere", func(t *testing.T) {
+		affected, err := repo.Select().Where("stock < ?", 10).UpdateWhere(ctxA, map[string]any{"stock": 0})
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), affected)
+
+		affected, err = repo.Select().Where("stock = ?", 0).UpdateWhere(ctxB, map[string]any{"stock": 100})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), affected)
+
+		_, err = repo.Select().UpdateWhere(ctxA, map[string]any{"id = 1; --": 0})
+		assert.Error(t, err)
+	})
+
+	t.Run("DeleteWhere", func(t *testing.T) {
+		affected, err := repo.Select().Where("sku = ?", "upsert").DeleteWhere(ctxB)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), affected)
+
+		affected, err = repo.Select().Where("sku = ?", "upsert").DeleteWhere(ctxA)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), affected)
+
+		affected, err = repo.Select().Where("stock >= ?", 500).DeleteWhere(ctxA)
+		require.NoError(t, err)
+		assert.Equal(t, int64(500), affected)
+
+		count, err := repo.Count(ctxA)
+		require.NoError(t, err)
+		assert.Equal(t, 500, count)
+	})
+}
+
+func TestBulkHooks(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE hook_models (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE)")
+	require.NoError(t, err)
+
+	ctx := WithDB(context.Background(), db)
+	repo := NewRepository[any, *HookModel](nil)
+
+	models := []*HookModel{{Name: "one"}, {Name: "two"}}
+	require.NoError(t, repo.CreateMany(ctx, models))
+	for _, m := range models {
+		assert.Equal(t, []string{"BeforeCreate", "AfterCreate"}, m.Log)
+		assert.NotZero(t, m.ID)
+	}
+	assert.Greater(t, models[1].ID, models[0].ID)
+
+	skipped := []*HookModel{{Name: "three"}}
+	require.NoError(t, repo.CreateMany(SkipHooks(ctx), skipped))
+	assert.Empty(t, skipped[0].Log)
+
+	_, err = repo.Select().Where("name = ?", "one").UpdateWhere(ctx, map[string]any{"name": "fail_before_update"})
+	require.NoError(t, err)
+
+	// BeforeUpdate is called on the loaded records and aborts the update
+	affected, err := repo.Select().Where("name = ?", "fail_before_update").UpdateWhere(ctx, map[string]any{"name": "renamed"})
+	assert.EqualError(t, err, "fail_before_update")
+	assert.Equal(t, int64(0), affected)
+
+	affected, err = repo.Select().Where("name = ?", "fail_before_update").UpdateWhere(SkipHooks(ctx), map[string]any{"name": "renamed"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	affected, err = repo.Select().DeleteWhere(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+
+	// Upsert calls the create or update hooks, depending on what happened. BeforeCreate is always
+	// called, as it may change the values that conflict.
+	in := &HookModel{Name: "upserted"}
+	created, err := repo.Upsert(ctx, in, []string{"name"}, "name")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BeforeCreate"}, in.Log)
+	assert.Equal(t, []string{"AfterCreate"}, created.Log)
+
+	in = &HookModel{Name: "upserted"}
+	updated, err := repo.Upsert(ctx, in, []string{"name"}, "name")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, updated.ID)
+	assert.Equal(t, []string{"BeforeCreate", "BeforeUpdate"}, in.Log)
+	assert.Equal(t, []string{"AfterUpdate"}, updated.Log)
+}
+
+func TestUpsertBeforeCreate(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE)")
+	require.NoError(t, err)
+
+	ctx := WithDB(context.Background(), db)
+	repo := NewRepository[any, *Tag](nil)
+
+	created, err := repo.Upsert(ctx, &Tag{Name: "go"}, []string{"name"}, "name")
+	require.NoError(t, err)
+
+	// The conflict is found on the name set by BeforeCreate
+	updated, err := repo.Upsert(ctx, &Tag{Name: "Go"}, []string{"name"}, "name")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, updated.ID)
+	assert.True(t, updated.Updated)
+}
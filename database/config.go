@@ -11,6 +11,8 @@ import (
 type Config struct {
 	Type   string `json:"type"`
 	Config json.RawMessage
+	// Replicas are read replicas of this database, reads outside of transactions go to them
+	Replicas []Config `json:"replicas"`
 }
 
 func (c Config) Create(ctx context.Context) (Database, error) {
@@ -24,7 +26,19 @@ func (c Config) Create(ctx context.Context) (Database, error) {
 		return nil, fmt.Errorf("failed to apply migrations: %w", err)
 	}
 
-	return db, nil
+	if len(c.Replicas) == 0 {
+		return db, nil
+	}
+
+	// The replicas receive their schema from the primary, so they are not migrated
+	replicas := make([]Database, len(c.Replicas))
+	for i, rc := range c.Replicas {
+		replicas[i], err = rc.create()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create read replica: %w", err)
+		}
+	}
+	return NewReplicated(db, replicas...), nil
 }
 
 func (c Config) create() (Database, error) {
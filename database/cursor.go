@@ -0,0 +1,295 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	cursorSecretMu sync.RWMutex
+	cursorSecret   = randomCursorSecret()
+)
+
+func randomCursorSecret() []byte {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return b
+}
+
+// SetCursorSecret sets the key used to sign pagination cursors. Without it, a random key is
+// used, which invalidates all cursors when the application restarts.
+func SetCursorSecret(secret []byte) {
+	cursorSecretMu.Lock()
+	defer cursorSecretMu.Unlock()
+
+	cursorSecret = bytes.Clone(secret)
+}
+
+func signCursor(payload []byte) []byte {
+	cursorSecretMu.RLock()
+	defer cursorSecretMu.RUnlock()
+
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// CursorPage is a page of records returned by a keyset paginated query
+type CursorPage[T any] struct {
+	Items []T
+	// NextCursor points to the record after the last item, it is empty on the last page
+	NextCursor string
+	HasMore    bool
+}
+
+// cursorValue holds a single key value of a cursor, keeping its type so that it compares the
+// same way as the stored value when it's bound as a query argument again.
+type cursorValue struct {
+	Int    *int64     `json:"i,omitempty"`
+	Float  *float64   `json:"f,omitempty"`
+	String *string    `json:"s,omitempty"`
+	Bool   *bool      `json:"b,omitempty"`
+	Bytes  []byte     `json:"y,omitempty"`
+	Time   *time.Time `json:"t,omitempty"`
+}
+
+func (c cursorValue) value() any {
+	switch {
+	case c.Int != nil:
+		return *c.Int
+	case c.Float != nil:
+		return *c.Float
+	case c.String != nil:
+		return *c.String
+	case c.Bool != nil:
+		return *c.Bool
+	case c.Bytes != nil:
+		return c.Bytes
+	case c.Time != nil:
+		return *c.Time
+	default:
+		return nil
+	}
+}
+
+func encodeCursor(values []any) (string, error) {
+	encoded := make([]cursorValue, len(values))
+	for i, v := range values {
+		v, err := driver.DefaultParameterConverter.ConvertValue(v)
+		if err != nil {
+			return "", err
+		}
+		switch v := v.(type) {
+		case int64:
+			encoded[i].Int = &v
+		case float64:
+			encoded[i].Float = &v
+		case string:
+			encoded[i].String = &v
+		case bool:
+			encoded[i].Bool = &v
+		case []byte:
+			encoded[i].Bytes = v
+		case time.Time:
+			encoded[i].Time = &v
+		case nil:
+		default:
+			return "", fmt.Errorf("unsupported cursor value type %T", v)
+		}
+	}
+
+	payload, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signCursor(payload)), nil
+}
+
+func decodeCursor(cursor string) ([]any, error) {
+	p, s, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || !hmac.Equal(signature, signCursor(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var encoded []cursorValue
+	if err := json.Unmarshal(payload, &encoded); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]any, len(encoded))
+	for i, v := range encoded {
+		values[i] = v.value()
+	}
+	return values, nil
+}
+
+// keyset returns the ordering of the query, with the id added as the final tie-breaker
+func (q *QueryBuilder[S, T]) keyset() []orderColumn {
+	keys := slices.Clone(q.orderColumns)
+	for _, k := range keys {
+		if k.name == "id" {
+			return keys
+		}
+	}
+
+	direction := ASC
+	if len(keys) > 0 {
+		direction = keys[len(keys)-1].direction
+	}
+	return append(keys, orderColumn{name: "id", direction: direction})
+}
+
+// keyValues returns the values of the keyset columns for the given record
+func keyValues[S Schema, T Model[S, T]](record T, keys []orderColumn) []any {
+	fields := record.Fields()
+	values := record.Values()
+
+	res := make([]any, len(keys))
+	for i, k := range keys {
+		if k.name == "id" {
+			res[i] = record.GetID()
+			continue
+		}
+		res[i] = values[slices.Index(fields, k.name)]
+	}
+	return res
+}
+
+// Paginate runs the query with keyset pagination. It returns up to size records that follow
+// the record the cursor points to, or the first page when the cursor is empty.
+//
+// Records are ordered by the Order clauses of the query, with the id as final tie-breaker.
+// Only model fields can be used as order columns, and they should not contain NULL values.
+func (q *QueryBuilder[S, T]) Paginate(ctx context.Context, cursor string, size int) (*CursorPage[T], error) {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "querybuilder.paginate")
+	defer span.End()
+
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid page size: %d", size)
+	}
+
+	keys := q.keyset()
+	fields := q.repo.zero.Fields()
+	for _, k := range keys {
+		if k.name != "id" && !slices.Contains(fields, k.name) {
+			return nil, fmt.Errorf("can not paginate on %s, it is not a field of %s", k.name, q.tableName)
+		}
+	}
+
+	// Work on a copy to keep the original query reusable
+	q2 := *q
+	q2.conditions = slices.Clone(q.conditions)
+	q2.args = slices.Clone(q.args)
+	q2.orderBy = nil
+	q2.orderColumns = nil
+	for _, k := range keys {
+		q2.Order(k.name, k.direction)
+	}
+	q2.Limit(size + 1)
+	q2.hasOffset = false
+
+	if cursor != "" {
+		values, err := decodeCursor(cursor)
+		if err != nil || len(values) != len(keys) {
+			return nil, ErrInvalidCursor
+		}
+
+		// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+		var alternatives []string
+		var args []any
+		for i, k := range keys {
+			var parts []string
+			for j := 0; j < i; j++ {
+				parts = append(parts, keys[j].name+" = ?")
+				args = append(args, values[j])
+			}
+			op := ">"
+			if k.direction == DESC {
+				op = "<"
+			}
+			parts = append(parts, k.name+" "+op+" ?")
+			args = append(args, values[i])
+			alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+		}
+		q2.Where("("+strings.Join(alternatives, " OR ")+")", args...)
+	}
+
+	records, err := q2.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CursorPage[T]{Items: records}
+	if len(records) > size {
+		page.Items = records[:size]
+		page.HasMore = true
+
+		page.NextCursor, err = encodeCursor(keyValues(page.Items[size-1], keys))
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// Iter runs the query and streams the results one record at a time instead of loading the
+// whole result set in memory. The iteration stops after the first error.
+func (q *QueryBuilder[S, T]) Iter(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "querybuilder.iter")
+		defer span.End()
+
+		var zero T
+
+		query, args := q.Build(ctx)
+		rows, err := FromContext(ctx).QueryContext(ctx, query, args...)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			model, err := zero.Scan(ctx, q.repo.schema, rows)
+			if err != nil {
+				span.RecordError(err)
+				yield(zero, err)
+				return
+			}
+			if !yield(model, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			span.RecordError(err)
+			yield(zero, err)
+		}
+	}
+}
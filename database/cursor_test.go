@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginate(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, price REAL)")
+	require.NoError(t, err)
+
+	ctx := WithDB(context.Background(), db)
+	repo := NewRepository[*schema, TestProduct](&schema{})
+
+	// Prices repeat, so the id is needed as tie-breaker
+	for i := range 25 {
+		_, err := repo.Create(ctx, TestProduct{Name: fmt.Sprintf("product-%02d", i), Price: float64(i % 5)})
+		require.NoError(t, err)
+	}
+
+	t.Run("Walks all pages", func(t *testing.T) {
+		var seen []int
+		cursor := ""
+		pages := 0
+		for {
+			page, err := repo.Select().Order("price", DESC).Paginate(ctx, cursor, 10)
+			require.NoError(t, err)
+			pages++
+
+			for i, p := range page.Items {
+				seen = append(seen, p.ID)
+				if i > 0 {
+					assert.LessOrEqual(t, p.Price, page.Items[i-1].Price)
+				}
+			}
+			if !page.HasMore {
+				assert.Empty(t, page.NextCursor)
+				break
+			}
+			cursor = page.NextCursor
+		}
+
+		assert.Equal(t, 3, pages)
+		assert.Len(t, seen, 25)
+		assert.ElementsMatch(t, seen, uniqueInts(seen))
+	})
+
+	t.Run("Respects conditions", func(t *testing.T) {
+		page, err := repo.Select().Where("price = ?", 1.0).Paginate(ctx, "", 3)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 3)
+		assert.True(t, page.HasMore)
+
+		page, err = repo.Select().Where("price = ?", 1.0).Paginate(ctx, page.NextCursor, 3)
+		require.NoError(t, err)
+		assert.Len(t, page.Items, 2)
+		assert.False(t, page.HasMore)
+	})
+
+	t.Run("Rejects tampered cursors", func(t *testing.T) {
+		page, err := repo.Select().Paginate(ctx, "", 5)
+		require.NoError(t, err)
+
+		_, err = repo.Select().Paginate(ctx, page.NextCursor+"x", 5)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, err = repo.Select().Paginate(ctx, "garbage", 5)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("Rejects unknown order columns", func(t *testing.T) {
+		_, err := repo.Select().Order("LOWER(name)", ASC).Paginate(ctx, "", 5)
+		assert.Error(t, err)
+	})
+
+	t.Run("Iter streams all rows", func(t *testing.T) {
+		count := 0
+		for p, err := range repo.Select().Where("price > ?", 2.0).Iter(ctx) {
+			require.NoError(t, err)
+			assert.Greater(t, p.Price, 2.0)
+			count++
+		}
+		assert.Equal(t, 10, count)
+	})
+
+	t.Run("Iter stops early", func(t *testing.T) {
+		count := 0
+		for _, err := range repo.Select().Iter(ctx) {
+			require.NoError(t, err)
+			count++
+			if count == 3 {
+				break
+			}
+		}
+		assert.Equal(t, 3, count)
+	})
+}
+
+func uniqueInts(values []int) []int {
+	seen := make(map[int]bool)
+	var res []int
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			res = append(res, v)
+		}
+	}
+	return res
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"reflect"
+	"time"
 )
 
 var (
-	ErrDuplicate = errors.New("duplicate record")
+	ErrDuplicate   = errors.New("duplicate record")
+	ErrStaleObject = errors.New("stale object")
 )
 
 // contextKey is a type for context keys specific to the multitenancy package
@@ -113,6 +117,112 @@ func shouldSkipDomainScope(ctx context.Context) bool {
 	return skip
 }
 
+// Timestamped is the interface that models can implement to let the repository maintain
+// the created_at and updated_at columns.
+type Timestamped interface {
+	GetCreatedAt() time.Time
+	SetCreatedAt(time.Time)
+	GetUpdatedAt() time.Time
+	SetUpdatedAt(time.Time)
+}
+
+// TimestampsModel is a helper struct that models can embed to implement Timestamped.
+type TimestampsModel struct {
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (m *TimestampsModel) GetCreatedAt() time.Time {
+	return m.CreatedAt
+}
+
+func (m *TimestampsModel) SetCreatedAt(t time.Time) {
+	m.CreatedAt = t
+}
+
+func (m *TimestampsModel) GetUpdatedAt() time.Time {
+	return m.UpdatedAt
+}
+
+func (m *TimestampsModel) SetUpdatedAt(t time.Time) {
+	m.UpdatedAt = t
+}
+
+// SoftDeletable is the interface that models can implement to turn deletes into setting
+// the deleted_at column. Soft-deleted records are excluded from queries unless the
+// context was created with WithDeleted.
+type SoftDeletable interface {
+	GetDeletedAt() *time.Time
+	SetDeletedAt(*time.Time)
+}
+
+// SoftDeleteModel is a helper struct that models can embed to implement SoftDeletable.
+type SoftDeleteModel struct {
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+func (m *SoftDeleteModel) GetDeletedAt() *time.Time {
+	return m.DeletedAt
+}
+
+func (m *SoftDeleteModel) SetDeletedAt(t *time.Time) {
+	m.DeletedAt = t
+}
+
+// IsDeleted returns true if the record has been soft-deleted.
+func (m *SoftDeleteModel) IsDeleted() bool {
+	return m.DeletedAt != nil
+}
+
+// Versioned is the interface that models can implement to enable optimistic locking.
+// Updates only succeed when the version column still matches the loaded version, and
+// increment it on success.
+type Versioned interface {
+	GetVersion() int
+	SetVersion(int)
+}
+
+// VersionedModel is a helper struct that models can embed to implement Versioned.
+type VersionedModel struct {
+	Version int `json:"version"`
+}
+
+func (m *VersionedModel) GetVersion() int {
+	return m.Version
+}
+
+func (m *VersionedModel) SetVersion(v int) {
+	m.Version = v
+}
+
+// StaleObjectError is returned by Repository.Update when a versioned record was changed
+// or removed since it was loaded. It matches ErrStaleObject with errors.Is.
+type StaleObjectError struct {
+	Table   string
+	ID      any
+	Version int
+}
+
+func (e *StaleObjectError) Error() string {
+	return fmt.Sprintf("stale object: %s with id %v is no longer at version %d", e.Table, e.ID, e.Version)
+}
+
+func (e *StaleObjectError) Is(target error) bool {
+	return target == ErrStaleObject
+}
+
+type withDeletedKey struct{}
+
+// WithDeleted returns a new context that tells the repository to include soft-deleted records in queries.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withDeletedKey{}, true)
+}
+
+func shouldIncludeDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(withDeletedKey{}).(bool)
+	return include
+}
+
 // Model is the interface that all database models must implement
 type Model[S Schema, T any] interface {
 	// TableName returns the name of the database table for this model
@@ -170,3 +280,20 @@ func shouldSkipHooks(ctx context.Context) bool {
 	skip, _ := ctx.Value(skipHooksKey{}).(bool)
 	return skip
 }
+
+type explicitIDKey struct{}
+
+// withExplicitID returns a new context in which Create inserts the id that is set on an
+// auto-increment model, instead of leaving it to the database
+func withExplicitID(ctx context.Context) context.Context {
+	return context.WithValue(ctx, explicitIDKey{}, true)
+}
+
+func hasExplicitID[S Schema, T Model[S, T]](ctx context.Context, model T) bool {
+	explicit, _ := ctx.Value(explicitIDKey{}).(bool)
+	if !explicit || !model.HasAutoIncrementID() {
+		return false
+	}
+	id := model.GetID()
+	return id != nil && !reflect.ValueOf(id).IsZero()
+}
@@ -0,0 +1,382 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrNoEncryptionKey      = errors.New("no encryption key configured")
+	ErrUnknownEncryptionKey = errors.New("value was encrypted with an unknown key")
+	ErrInvalidCiphertext    = errors.New("invalid encrypted value")
+
+	encryptionMu   sync.RWMutex
+	encryptionKeys []encryptionKey
+)
+
+const (
+	encryptedPrefix   = "enc:"
+	encryptionVersion = 1
+
+	randomized    byte = 'r'
+	deterministic byte = 'd'
+)
+
+type encryptionKey struct {
+	id          [4]byte
+	nonceKey    []byte
+	fingerprint []byte
+	aead        cipher.AEAD
+}
+
+// deriveKey derives a subkey for a single purpose from a secret, so the cipher, the deterministic
+// nonces and the fingerprints never share a key
+func deriveKey(secret []byte, purpose string, length int) ([]byte, error) {
+	return hkdf.Key(sha256.New, secret, nil, "tracks encryption "+purpose, length)
+}
+
+// SetEncryptionKeys sets the keys used to encrypt model attributes. The first key encrypts new
+// values, all keys are used to decrypt, so a new key can be added in front while values encrypted
+// with the older keys are still readable. Use Reencrypt to move all values to the first key.
+func SetEncryptionKeys(secrets ...[]byte) error {
+	keys := make([]encryptionKey, len(secrets))
+	for i, secret := range secrets {
+		key, err := deriveKey(secret, "aes-gcm", 32)
+		if err != nil {
+			return err
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return err
+		}
+		keys[i] = encryptionKey{aead: aead}
+
+		if keys[i].nonceKey, err = deriveKey(secret, "nonce", 32); err != nil {
+			return err
+		}
+		if keys[i].fingerprint, err = deriveKey(secret, "fingerprint", 32); err != nil {
+			return err
+		}
+		id, err := deriveKey(secret, "key id", len(keys[i].id))
+		if err != nil {
+			return err
+		}
+		copy(keys[i].id[:], id)
+	}
+
+	encryptionMu.Lock()
+	defer encryptionMu.Unlock()
+
+	encryptionKeys = keys
+	return nil
+}
+
+func primaryKey() (encryptionKey, error) {
+	encryptionMu.RLock()
+	defer encryptionMu.RUnlock()
+
+	if len(encryptionKeys) == 0 {
+		return encryptionKey{}, ErrNoEncryptionKey
+	}
+	return encryptionKeys[0], nil
+}
+
+func findKey(id []byte) (encryptionKey, bool) {
+	encryptionMu.RLock()
+	defer encryptionMu.RUnlock()
+
+	for _, k := range encryptionKeys {
+		if bytes.Equal(k.id[:], id) {
+			return k, true
+		}
+	}
+	return encryptionKey{}, false
+}
+
+// encrypt encrypts the plaintext with the primary key. In deterministic mode, the nonce is
+// derived from the plaintext, so equal values give equal ciphertexts.
+func encrypt(plaintext string, mode byte) (string, error) {
+	key, err := primaryKey()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, key.aead.NonceSize())
+	if mode == deterministic {
+		mac := hmac.New(sha256.New, key.nonceKey)
+		mac.Write([]byte(plaintext))
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	header := append([]byte{encryptionVersion, mode}, key.id[:]...)
+	out := append(header, nonce...)
+	out = key.aead.Seal(out, nonce, []byte(plaintext), header)
+	return encryptedPrefix + base64.RawStdEncoding.EncodeToString(out), nil
+}
+
+// decrypt returns the plaintext of an encrypted value, with the mode and whether it was
+// encrypted with the primary key. Values without the encryption prefix were stored before the
+// attribute was encrypted and are returned as is.
+func decrypt(value string) (plaintext string, mode byte, current bool, err error) {
+	encoded, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, 0, false, nil
+	}
+
+	raw, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) < 6 || raw[0] != encryptionVersion {
+		return "", 0, false, ErrInvalidCiphertext
+	}
+	header, rest := raw[:6], raw[6:]
+
+	key, ok := findKey(header[2:6])
+	if !ok {
+		return "", 0, false, ErrUnknownEncryptionKey
+	}
+	if len(rest) < key.aead.NonceSize() {
+		return "", 0, false, ErrInvalidCiphertext
+	}
+
+	nonce, ciphertext := rest[:key.aead.NonceSize()], rest[key.aead.NonceSize():]
+	plain, err := key.aead.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return "", 0, false, ErrInvalidCiphertext
+	}
+
+	primary, _ := primaryKey()
+	return string(plain), header[1], primary.id == key.id, nil
+}
+
+func scanEncrypted(src any) (string, error) {
+	var value string
+	switch v := src.(type) {
+	case nil:
+		return "", nil
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return "", fmt.Errorf("can not scan %T into an encrypted string", src)
+	}
+
+	plaintext, _, _, err := decrypt(value)
+	return plaintext, err
+}
+
+// encryptedValue is implemented by the encrypted attribute types
+type encryptedValue interface {
+	fingerprint() string
+}
+
+// fingerprint identifies a plaintext without revealing it, so changes to encrypted attributes can
+// be detected in the audit log
+func fingerprint(plaintext string) string {
+	key, err := primaryKey()
+	if err != nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key.fingerprint)
+	mac.Write([]byte(plaintext))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// EncryptedString is a string attribute that is encrypted with AES-GCM before it is written to
+// the database, and decrypted when it's scanned. Every write uses a random nonce, so encrypted
+// values can't be compared in queries; use DeterministicString for that.
+type EncryptedString string
+
+// Value encrypts the string for the database
+func (s EncryptedString) Value() (driver.Value, error) {
+	return encrypt(string(s), randomized)
+}
+
+// Scan decrypts a value read from the database
+func (s *EncryptedString) Scan(src any) error {
+	v, err := scanEncrypted(src)
+	*s = EncryptedString(v)
+	return err
+}
+
+func (s EncryptedString) fingerprint() string {
+	return fingerprint(string(s))
+}
+
+// DeterministicString is an encrypted string attribute whose ciphertext only depends on the
+// plaintext and the key, so it can be used in equality lookups:
+//
+//	repo.FindBy(ctx, map[string]any{"email": database.DeterministicString(email)})
+//
+// This reveals which records hold equal values, so only use it for columns that need lookups.
+// Lookups only match values encrypted with the current key, run Reencrypt after a key rotation.
+type DeterministicString string
+
+// Value encrypts the string for the database
+func (s DeterministicString) Value() (driver.Value, error) {
+	return encrypt(string(s), deterministic)
+}
+
+// Scan decrypts a value read from the database
+func (s *DeterministicString) Scan(src any) error {
+	v, err := scanEncrypted(src)
+	*s = DeterministicString(v)
+	return err
+}
+
+func (s DeterministicString) fingerprint() string {
+	return fingerprint(string(s))
+}
+
+// SkippedValue is an encrypted value that Reencrypt left as is, because its key isn't configured
+type SkippedValue struct {
+	Table  string
+	Column string
+	RowID  int64
+}
+
+// Reencrypt encrypts all encrypted values in the database that were not encrypted with the
+// current key again, keeping their mode. It finds the values by their prefix, so it doesn't need
+// to know the models, and returns the number of updated values. Values with the prefix that
+// aren't valid encrypted values, like plain text that happens to start with it, are skipped.
+//
+// Values that were encrypted with a key that is no longer configured are skipped as well and
+// returned, so they can be reported. Configure their key again to re-encrypt them.
+func Reencrypt(ctx context.Context, db Database) (int, []SkippedValue, error) {
+	if _, err := primaryKey(); err != nil {
+		return 0, nil, err
+	}
+
+	var updated int
+	var skipped []SkippedValue
+	err := WithTransaction(WithDB(ctx, db), func(ctx context.Context) error {
+		tx := FromContext(ctx)
+
+		rows, err := tx.QueryContext(ctx, `
+			SELECT name FROM sqlite_master
+			WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+			AND sql NOT LIKE 'CREATE VIRTUAL%' AND sql NOT LIKE '%WITHOUT ROWID%'
+			ORDER BY name`)
+		if err != nil {
+			return err
+		}
+		var tables []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return err
+			}
+			tables = append(tables, name)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, table := range tables {
+			n, s, err := reencryptTable(ctx, tx, table)
+			if err != nil {
+				return fmt.Errorf("failed to re-encrypt %s: %w", table, err)
+			}
+			updated += n
+			skipped = append(skipped, s...)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return updated, skipped, nil
+}
+
+func reencryptTable(ctx context.Context, db Database, table string) (int, []SkippedValue, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", strings.ReplaceAll(table, "'", "''")))
+	if err != nil {
+		return 0, nil, err
+	}
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		columns = append(columns, name)
+	}
+	rows.Close()
+
+	type change struct {
+		rowid  int64
+		column string
+		value  string
+	}
+	var changes []change
+	var skipped []SkippedValue
+
+	for _, column := range columns {
+		rows, err := db.QueryContext(ctx,
+			fmt.Sprintf(`SELECT rowid, "%[2]s" FROM "%[1]s" WHERE typeof("%[2]s") = 'text' AND "%[2]s" LIKE ?`, table, column),
+			encryptedPrefix+"%")
+		if err != nil {
+			return 0, nil, err
+		}
+		for rows.Next() {
+			var rowid int64
+			var value string
+			if err := rows.Scan(&rowid, &value); err != nil {
+				rows.Close()
+				return 0, nil, err
+			}
+
+			plaintext, mode, current, err := decrypt(value)
+			if errors.Is(err, ErrInvalidCiphertext) || current {
+				continue
+			}
+			if errors.Is(err, ErrUnknownEncryptionKey) {
+				skipped = append(skipped, SkippedValue{Table: table, Column: column, RowID: rowid})
+				continue
+			}
+			if err != nil {
+				rows.Close()
+				return 0, nil, fmt.Errorf("column %s of row %d: %w", column, rowid, err)
+			}
+			value, err = encrypt(plaintext, mode)
+			if err != nil {
+				rows.Close()
+				return 0, nil, err
+			}
+			changes = append(changes, change{rowid: rowid, column: column, value: value})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	for _, c := range changes {
+		_, err := db.ExecContext(ctx, fmt.Sprintf(`UPDATE "%s" SET "%s" = ? WHERE rowid = ?`, table, c.column), c.value, c.rowid)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	return len(changes), skipped, nil
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Credential struct {
+	ID     int
+	Email  DeterministicString
+	APIKey EncryptedString
+	AuditedModel
+}
+
+func (c *Credential) TableName() string { return "credentials" }
+func (c *Credential) Fields() []string  { return []string{"email", "api_key"} }
+func (c *Credential) Values() []any     { return []any{c.Email, c.APIKey} }
+func (c *Credential) Scan(ctx context.Context, schema any, row Scanner) (*Credential, error) {
+	var res Credential
+	err := row.Scan(&res.ID, &res.Email, &res.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+func (c *Credential) HasAutoIncrementID() bool { return true }
+func (c *Credential) GetID() any               { return c.ID }
+
+func TestEncryptedAttributes(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE credentials (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT, api_key TEXT)")
+	require.NoError(t, err)
+
+	require.NoError(t, SetEncryptionKeys([]byte("first key")))
+	t.Cleanup(func() { _ = SetEncryptionKeys() })
+
+	ctx := WithDB(context.Background(), db)
+	require.NoError(t, migrateFramework(ctx, db, CentralDatabase))
+	repo := NewRepository[any, *Credential](nil)
+
+	created, err := repo.Create(ctx, &Credential{Email: "jane@example.com", APIKey: "sk_live_123"})
+	require.NoError(t, err)
+	assert.Equal(t, EncryptedString("sk_live_123"), created.APIKey)
+
+	stored := func(column string) string {
+		var v string
+		require.NoError(t, db.QueryRow("SELECT "+column+" FROM credentials WHERE id = ?", created.ID).Scan(&v))
+		return v
+	}
+
+	t.Run("Values are encrypted at rest", func(t *testing.T) {
+		assert.True(t, strings.HasPrefix(stored("api_key"), "enc:"))
+		assert.NotContains(t, stored("api_key"), "sk_live_123")
+		assert.NotContains(t, stored("email"), "jane")
+	})
+
+	t.Run("Deterministic values support lookups", func(t *testing.T) {
+		found, err := repo.FindBy(ctx, map[string]any{"email": DeterministicString("jane@example.com")})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, created.ID, found[0].ID)
+	})
+
+	t.Run("Randomized values differ per write", func(t *testing.T) {
+		a, err := EncryptedString("same").Value()
+		require.NoError(t, err)
+		b, err := EncryptedString("same").Value()
+		require.NoError(t, err)
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("Plain text values are read as is", func(t *testing.T) {
+		_, err := db.Exec("INSERT INTO credentials (email, api_key) VALUES ('legacy@example.com', 'plain')")
+		require.NoError(t, err)
+
+		found, err := repo.Select().Where("api_key = ?", "plain").First(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, EncryptedString("plain"), found.APIKey)
+	})
+
+	t.Run("Audit log holds no plain text", func(t *testing.T) {
+		history, err := repo.History(ctx, created.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, history)
+		assert.NotEqual(t, "sk_live_123", history[0].Changes["api_key"].New)
+	})
+
+	t.Run("Key rotation and re-encryption", func(t *testing.T) {
+		before := stored("api_key")
+
+		require.NoError(t, SetEncryptionKeys([]byte("second key"), []byte("first key")))
+
+		// Old values are still readable
+		found, err := repo.FindByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, EncryptedString("sk_live_123"), found.APIKey)
+
+		// Plain text that looks like an encrypted value is left alone
+		_, err = db.Exec("CREATE TABLE notes (body TEXT)")
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO notes (body) VALUES ('enc: not encrypted')")
+		require.NoError(t, err)
+
+		n, skipped, err := Reencrypt(context.Background(), db)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Empty(t, skipped)
+		assert.NotEqual(t, before, stored("api_key"))
+
+		// Nothing left to re-encrypt, and the old key is no longer needed
+		n, _, err = Reencrypt(context.Background(), db)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		require.NoError(t, SetEncryptionKeys([]byte("second key")))
+		found, err = repo.FindByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, EncryptedString("sk_live_123"), found.APIKey)
+
+		byEmail, err := repo.FindBy(ctx, map[string]any{"email": DeterministicString("jane@example.com")})
+		require.NoError(t, err)
+		assert.Len(t, byEmail, 1)
+	})
+
+	t.Run("Unknown keys fail", func(t *testing.T) {
+		require.NoError(t, SetEncryptionKeys([]byte("other key")))
+		_, err := repo.FindByID(ctx, created.ID)
+		assert.ErrorIs(t, err, ErrUnknownEncryptionKey)
+	})
+
+	t.Run("Reencrypt skips values of unknown keys", func(t *testing.T) {
+		require.NoError(t, SetEncryptionKeys([]byte("other key")))
+		before := stored("api_key")
+
+		n, skipped, err := Reencrypt(context.Background(), db)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assert.Equal(t, []SkippedValue{
+			{Table: "credentials", Column: "email", RowID: int64(created.ID)},
+			{Table: "credentials", Column: "api_key", RowID: int64(created.ID)},
+		}, skipped)
+		assert.Equal(t, before, stored("api_key"))
+	})
+}
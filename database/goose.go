@@ -3,10 +3,12 @@ package database
 import (
 	"context"
 	"database/sql"
+	"embed"
 	"errors"
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -23,6 +25,37 @@ const (
 	TenantDatabase Type = "tenant"
 )
 
+// migrations holds the migrations of the tables the framework needs itself, like the audit log
+//
+//go:embed migrations
+var migrations embed.FS
+
+// frameworkVersionTable is the goose version table of the framework migrations, which keeps their
+// versions apart from the migrations of the application
+const frameworkVersionTable = "tracks_db_version"
+
+// migrateFramework applies the framework migrations of the given database type. They're applied
+// with every migration of a database, so the application never has to copy them.
+func migrateFramework(ctx context.Context, db *sql.DB, dbType Type) error {
+	fsys, err := fs.Sub(migrations, path.Join("migrations", string(dbType)))
+	if err != nil {
+		return err
+	}
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, fsys,
+		goose.WithTableName(frameworkVersionTable),
+		goose.WithDisableGlobalRegistry(true))
+	if errors.Is(err, goose.ErrNoMigrations) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := provider.Up(ctx); err != nil {
+		return fmt.Errorf("failed to apply the framework migrations: %w", err)
+	}
+	return nil
+}
+
 func MigrateUpFS(ctx context.Context, db Database, dbType Type, migrationsDir fs.FS) error {
 	goose.SetBaseFS(migrationsDir)
 	defer goose.SetBaseFS(nil)
@@ -46,7 +79,7 @@ func MigrateUpDir(ctx context.Context, db Database, dbType Type, migrationsDir s
 	if ctx == nil {
 		return errors.New("context can not be nil to apply migrations")
 	}
-	rawDB, ok := db.(*sql.DB)
+	rawDB, ok := sqlDB(db)
 	if !ok {
 		return errors.New("db is not a *sql.DB")
 	}
@@ -62,6 +95,9 @@ func MigrateUpDir(ctx context.Context, db Database, dbType Type, migrationsDir s
 			return fmt.Errorf("failed to apply migrations: %w", err)
 		}
 	}
+	if err := migrateFramework(ctx, rawDB, dbType); err != nil {
+		return err
+	}
 	fmt.Printf("Applied %s migrations\n", dbType)
 	return nil
 }
@@ -73,19 +109,13 @@ func RunGooseMigration(ctx context.Context, command string, dbType Type, dbPath
 	}
 
 	// Set up the database connection
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := openMigrationDB(dbPath)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %v", err)
+		return err
 	}
 	defer db.Close()
 
-	migrationsDir := filepath.Join("migrations", string(dbType))
-
-	// Set the database dialect
-	err = goose.SetDialect("sqlite3")
-	if err != nil {
-		return fmt.Errorf("failed to set dialect: %w", err)
-	}
+	migrationsDir := MigrationsDir(dbType)
 
 	// Run the Goose command
 	switch command {
@@ -93,12 +123,20 @@ func RunGooseMigration(ctx context.Context, command string, dbType Type, dbPath
 		if err := goose.UpContext(ctx, db, migrationsDir); err != nil {
 			return fmt.Errorf("failed to apply migrations: %w", err)
 		}
+		if err := migrateFramework(ctx, db, dbType); err != nil {
+			return err
+		}
 		fmt.Printf("Applied %s migrations\n", dbType)
 	case "down":
 		if err := goose.DownContext(ctx, db, migrationsDir); err != nil {
 			return fmt.Errorf("failed to revert migration: %w", err)
 		}
 		fmt.Printf("Reverted %s migration\n", dbType)
+	case "redo":
+		if err := goose.RedoContext(ctx, db, migrationsDir); err != nil {
+			return fmt.Errorf("failed to redo migration: %w", err)
+		}
+		fmt.Printf("Redid the last %s migration\n", dbType)
 	case "status":
 		if err := goose.StatusContext(ctx, db, migrationsDir); err != nil {
 			return fmt.Errorf("failed to get migration status: %w", err)
@@ -108,3 +146,133 @@ func RunGooseMigration(ctx context.Context, command string, dbType Type, dbPath
 	}
 	return nil
 }
+
+// MigrationsDir returns the directory, relative to the project root, that holds the migrations
+// of the given database type
+func MigrationsDir(dbType Type) string {
+	return filepath.Join("migrations", string(dbType))
+}
+
+// RollbackMigrations reverts the given number of most recent migrations. It stops early when no
+// applied migrations are left.
+func RollbackMigrations(ctx context.Context, dbType Type, dbPath string, steps int) error {
+	if dbPath == "" {
+		return errors.New("database path can not be empty")
+	}
+	if steps <= 0 {
+		return fmt.Errorf("invalid number of steps: %d", steps)
+	}
+
+	db, err := openMigrationDB(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for i := range steps {
+		version, err := goose.GetDBVersionContext(ctx, db)
+		if err != nil {
+			return fmt.Errorf("failed to get the database version: %w", err)
+		}
+		if version == 0 {
+			fmt.Printf("Reverted %d %s migrations, no applied migrations left\n", i, dbType)
+			return nil
+		}
+
+		if err := goose.DownContext(ctx, db, MigrationsDir(dbType)); err != nil {
+			return fmt.Errorf("failed to revert migration %d: %w", version, err)
+		}
+	}
+	fmt.Printf("Reverted %d %s migrations\n", steps, dbType)
+	return nil
+}
+
+// openMigrationDB opens the SQLite database at dbPath for goose
+func openMigrationDB(dbPath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+
+	// Set the database dialect
+	err = goose.SetDialect("sqlite3")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set dialect: %w", err)
+	}
+	return db, nil
+}
+
+// newMigrationProvider creates a goose provider for the migrations in migrationsDir. Unlike the
+// functions above, providers don't share global state, so they can migrate databases in parallel.
+// It returns nil when the directory has no migrations.
+func newMigrationProvider(db Database, migrationsDir string) (*goose.Provider, error) {
+	rawDB, ok := sqlDB(db)
+	if !ok {
+		return nil, errors.New("db is not a *sql.DB")
+	}
+
+	provider, err := goose.NewProvider(goose.DialectSQLite3, rawDB, os.DirFS(migrationsDir), goose.WithAllowOutofOrder(true))
+	if errors.Is(err, goose.ErrNoMigrations) {
+		return nil, nil
+	}
+	return provider, err
+}
+
+// ApplyMigrations applies the pending migrations in migrationsDir, and those of the framework, to
+// a database of the given type and returns the versions it applied from migrationsDir. It is safe
+// to call concurrently for different databases.
+func ApplyMigrations(ctx context.Context, db Database, dbType Type, migrationsDir string) ([]int64, error) {
+	rawDB, ok := sqlDB(db)
+	if !ok {
+		return nil, errors.New("db is not a *sql.DB")
+	}
+	if err := migrateFramework(ctx, rawDB, dbType); err != nil {
+		return nil, err
+	}
+
+	provider, err := newMigrationProvider(db, migrationsDir)
+	if err != nil || provider == nil {
+		return nil, err
+	}
+
+	results, err := provider.Up(ctx)
+	var applied []int64
+	for _, r := range results {
+		if r.Error == nil {
+			applied = append(applied, r.Source.Version)
+		}
+	}
+	if err != nil {
+		return applied, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// PendingMigrations returns the highest migration version applied to db and the versions in
+// migrationsDir that were not applied yet.
+func PendingMigrations(ctx context.Context, db Database, migrationsDir string) (current int64, pending []int64, err error) {
+	provider, err := newMigrationProvider(db, migrationsDir)
+	if err != nil {
+		return 0, nil, err
+	}
+	if provider == nil {
+		return 0, nil, nil
+	}
+
+	current, err = provider.GetDBVersion(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get the database version: %w", err)
+	}
+
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get the migration status: %w", err)
+	}
+	for _, s := range statuses {
+		if s.State == goose.StatePending {
+			pending = append(pending, s.Source.Version)
+		}
+	}
+	return current, pending, nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSON is a model attribute that is stored as JSON in a TEXT column, so structured values can be
+// used as normal model fields:
+//
+//	type Account struct {
+//		ID       int
+//		Settings database.JSON[Settings]
+//	}
+//
+// It is encoded as its value in JSON responses as well. NULL and empty columns scan into the zero
+// value of T.
+type JSON[T any] struct {
+	V T
+}
+
+// NewJSON wraps a value in a JSON attribute
+func NewJSON[T any](v T) JSON[T] {
+	return JSON[T]{V: v}
+}
+
+// Value encodes the value as JSON for the database
+func (j JSON[T]) Value() (driver.Value, error) {
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan decodes a JSON value read from the database
+func (j *JSON[T]) Scan(src any) error {
+	var zero T
+	j.V = zero
+
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return fmt.Errorf("can not scan %T into a JSON attribute", src)
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, &j.V)
+}
+
+// MarshalJSON encodes the value of the attribute
+func (j JSON[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.V)
+}
+
+// UnmarshalJSON decodes into the value of the attribute
+func (j *JSON[T]) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &j.V)
+}
+
+// UnmarshalYAML decodes into the value of the attribute, so JSON attributes can be set in fixtures
+func (j *JSON[T]) UnmarshalYAML(node *yaml.Node) error {
+	return node.Decode(&j.V)
+}
+
+// jsonPath turns a dotted path like address.city into a SQLite JSON path
+func jsonPath(path string) string {
+	if strings.HasPrefix(path, "$") {
+		return path
+	}
+	return "$." + path
+}
+
+// JSONExtract returns the SQL expression that extracts the value at path from a JSON column, for
+// use in Select, Where and Order. The path is a dotted path like address.city, or a full SQLite
+// JSON path starting with $.
+func JSONExtract(column, path string) string {
+	return fmt.Sprintf("json_extract(%s, '%s')", column, strings.ReplaceAll(jsonPath(path), "'", "''"))
+}
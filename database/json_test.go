@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type WorkspaceSettings struct {
+	Plan    string `json:"plan"`
+	Seats   int    `json:"seats"`
+	Billing struct {
+		Country string `json:"country"`
+	} `json:"billing"`
+}
+
+type Workspace struct {
+	ID       int                     `json:"id"`
+	Name     string                  `json:"name"`
+	Settings JSON[WorkspaceSettings] `json:"settings"`
+	Tags     JSON[[]string]          `json:"tags"`
+}
+
+func (w *Workspace) TableName() string { return "workspaces" }
+func (w *Workspace) Fields() []string  { return []string{"name", "settings", "tags"} }
+func (w *Workspace) Values() []any     { return []any{w.Name, w.Settings, w.Tags} }
+func (w *Workspace) Scan(ctx context.Context, schema any, row Scanner) (*Workspace, error) {
+	var res Workspace
+	err := row.Scan(&res.ID, &res.Name, &res.Settings, &res.Tags)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+func (w *Workspace) HasAutoIncrementID() bool { return true }
+func (w *Workspace) GetID() any               { return w.ID }
+
+func TestJSON(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE workspaces (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, settings TEXT, tags TEXT)")
+	require.NoError(t, err)
+
+	ctx := WithDB(context.Background(), db)
+	repo := NewRepository[any, *Workspace](nil)
+
+	pro := &Workspace{Name: "pro", Settings: NewJSON(WorkspaceSettings{Plan: "pro", Seats: 10}), Tags: NewJSON([]string{"a", "b"})}
+	pro.Settings.V.Billing.Country = "BE"
+	pro, err = repo.Create(ctx, pro)
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &Workspace{Name: "free", Settings: NewJSON(WorkspaceSettings{Plan: "free", Seats: 1})})
+	require.NoError(t, err)
+
+	t.Run("Values round trip", func(t *testing.T) {
+		found, err := repo.FindByID(ctx, pro.ID)
+		require.NoError(t, err)
+		assert.Equal(t, pro.Settings.V, found.Settings.V)
+		assert.Equal(t, []string{"a", "b"}, found.Tags.V)
+
+		var raw string
+		require.NoError(t, db.QueryRow("SELECT settings FROM workspaces WHERE id = ?", pro.ID).Scan(&raw))
+		assert.JSONEq(t, `{"plan":"pro","seats":10,"billing":{"country":"BE"}}`, raw)
+	})
+
+	t.Run("NULL scans into the zero value", func(t *testing.T) {
+		_, err := db.Exec("INSERT INTO workspaces (name) VALUES ('empty')")
+		require.NoError(t, err)
+
+		found, err := repo.Select().Where("name = ?", "empty").First(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, WorkspaceSettings{}, found.Settings.V)
+		assert.Nil(t, found.Tags.V)
+	})
+
+	t.Run("WhereJSON filters on a path", func(t *testing.T) {
+		found, err := repo.Select().WhereJSON("settings", "plan", "pro").Execute(ctx)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, pro.ID, found[0].ID)
+
+		found, err = repo.Select().WhereJSON("settings", "billing.country", "BE").Execute(ctx)
+		require.NoError(t, err)
+		assert.Len(t, found, 1)
+	})
+
+	t.Run("JSONExtract can be used in conditions and ordering", func(t *testing.T) {
+		found, err := repo.Select().
+			Where(JSONExtract("settings", "seats")+" >= ?", 1).
+			Order(JSONExtract("settings", "$.seats"), DESC).
+			Execute(ctx)
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, "pro", found[0].Name)
+
+		// The native SQLite operator works too
+		found, err = repo.Select().Where("settings->>'plan' = ?", "free").Execute(ctx)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "free", found[0].Name)
+	})
+
+	t.Run("Decodes from fixtures", func(t *testing.T) {
+		var node yaml.Node
+		require.NoError(t, yaml.Unmarshal([]byte("name: fixture\ntags: [x, y]\nsettings:\n  plan: team\n"), &node))
+
+		w, err := decodeFixture[any, *Workspace](node.Content[0])
+		require.NoError(t, err)
+		assert.Equal(t, []string{"x", "y"}, w.Tags.V)
+		assert.Equal(t, "team", w.Settings.V.Plan)
+	})
+
+	t.Run("Encodes as its value in JSON", func(t *testing.T) {
+		b, err := json.Marshal(&Workspace{Name: "x", Tags: NewJSON([]string{"t"})})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":0,"name":"x","settings":{"plan":"","seats":0,"billing":{"country":""}},"tags":["t"]}`, string(b))
+
+		var w Workspace
+		require.NoError(t, json.Unmarshal(b, &w))
+		assert.Equal(t, []string{"t"}, w.Tags.V)
+	})
+}
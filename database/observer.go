@@ -0,0 +1,221 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ObserverConfig configures the checks that run on the queries of a single request
+type ObserverConfig struct {
+	// SlowThreshold is the duration after which a query is reported as slow, zero disables the check
+	SlowThreshold time.Duration
+	// RepeatThreshold is the number of identical reads after which they are reported as an N+1
+	// query, zero disables the check
+	RepeatThreshold int
+	// Strict returns a QueryViolationError for a violation instead of logging a warning, and records
+	// it so the request can be failed
+	Strict bool
+}
+
+// QueryViolationError describes a query that failed one of the observer checks
+type QueryViolationError struct {
+	Kind     string
+	Query    string
+	Count    int
+	Duration time.Duration
+}
+
+func (e *QueryViolationError) Error() string {
+	if e.Kind == "slow" {
+		return fmt.Sprintf("slow query detected (%s): %s", e.Duration, e.Query)
+	}
+	return fmt.Sprintf("N+1 query detected (%d identical queries): %s", e.Count, e.Query)
+}
+
+// QueryStats collects the queries executed while handling a single request
+type QueryStats struct {
+	config ObserverConfig
+
+	mu       sync.Mutex
+	count    int
+	duration time.Duration
+	reads    map[string]int
+	err      *QueryViolationError
+}
+
+type queryStatsKey struct{}
+
+// WithQueryStats returns a new context that collects the statistics of all queries executed
+// through an observed database or a transaction.
+func WithQueryStats(ctx context.Context, config ObserverConfig) (context.Context, *QueryStats) {
+	stats := &QueryStats{
+		config: config,
+		reads:  make(map[string]int),
+	}
+	return context.WithValue(ctx, queryStatsKey{}, stats), stats
+}
+
+// QueryStatsFromContext returns the query statistics collected for the context, if any
+func QueryStatsFromContext(ctx context.Context) *QueryStats {
+	stats, _ := ctx.Value(queryStatsKey{}).(*QueryStats)
+	return stats
+}
+
+// Count returns the number of executed queries
+func (s *QueryStats) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.count
+}
+
+// Duration returns the total time spent executing queries
+func (s *QueryStats) Duration() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.duration
+}
+
+// Err returns the first violation of the queries in strict mode, if any
+func (s *QueryStats) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err == nil {
+		return nil
+	}
+	return s.err
+}
+
+// before records a query that is about to be executed and checks for N+1 queries
+func (s *QueryStats) before(ctx context.Context, query string, read bool) error {
+	s.mu.Lock()
+	s.count++
+	var repeated int
+	if read {
+		s.reads[query]++
+		repeated = s.reads[query]
+	}
+	s.mu.Unlock()
+
+	if s.config.RepeatThreshold > 0 && repeated == s.config.RepeatThreshold {
+		return s.violation(ctx, &QueryViolationError{Kind: "n+1", Query: query, Count: repeated})
+	}
+	return nil
+}
+
+// after records the duration of an executed query and checks whether it was slow
+func (s *QueryStats) after(ctx context.Context, query string, elapsed time.Duration) error {
+	s.mu.Lock()
+	s.duration += elapsed
+	s.mu.Unlock()
+
+	if s.config.SlowThreshold > 0 && elapsed > s.config.SlowThreshold {
+		return s.violation(ctx, &QueryViolationError{Kind: "slow", Query: query, Duration: elapsed})
+	}
+	return nil
+}
+
+// violation logs a warning, or records and returns the violation in strict mode
+func (s *QueryStats) violation(ctx context.Context, err *QueryViolationError) error {
+	if s.config.Strict {
+		s.mu.Lock()
+		if s.err == nil {
+			s.err = err
+		}
+		s.mu.Unlock()
+		return err
+	}
+	slog.WarnContext(ctx, err.Error())
+	return nil
+}
+
+// observe runs a query and records it in the statistics of the context. In strict mode, a
+// violation is returned. When the result of the query can be released, a repeated read isn't run
+// and the result of a slow read is released. Otherwise the query runs, and the violation is
+// returned next to its result, as a write has already been executed by then.
+func observe[R any](ctx context.Context, query string, read bool, run func() R, release func(R)) (R, error) {
+	var zero R
+	stats := QueryStatsFromContext(ctx)
+	if stats == nil {
+		return run(), nil
+	}
+
+	if err := stats.before(ctx, query, read); err != nil && release != nil {
+		return zero, err
+	}
+
+	start := time.Now()
+	res := run()
+
+	if err := stats.after(ctx, query, time.Since(start)); err != nil {
+		if release != nil {
+			release(res)
+			return zero, err
+		}
+		return res, err
+	}
+	return res, nil
+}
+
+func closeRows(rows *sql.Rows) {
+	if rows != nil {
+		_ = rows.Close()
+	}
+}
+
+// Observed is a Database that records all queries in the QueryStats of their context
+type Observed struct {
+	db Database
+}
+
+// Ensure Observed implements Database
+var _ Database = (*Observed)(nil)
+
+// NewObserved wraps the database so its queries are recorded in the QueryStats of the context
+func NewObserved(db Database) *Observed {
+	return &Observed{db: db}
+}
+
+// Primary returns the wrapped database
+func (o *Observed) Primary() Database {
+	return o.db
+}
+
+// QueryContext executes a query that returns rows
+func (o *Observed) QueryContext(ctx context.Context, query string, args ...any) (rows *sql.Rows, err error) {
+	rows, violation := observe(ctx, query, true, func() *sql.Rows {
+		rows, err = o.db.QueryContext(ctx, query, args...)
+		return rows
+	}, closeRows)
+	return rows, cmp.Or(err, violation)
+}
+
+// QueryRowContext executes a query that returns a single row. A violation can't be returned with
+// the row, it's only recorded in the QueryStats.
+func (o *Observed) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	row, _ := observe(ctx, query, true, func() *sql.Row {
+		return o.db.QueryRowContext(ctx, query, args...)
+	}, nil)
+	return row
+}
+
+// ExecContext executes a query that doesn't return rows
+func (o *Observed) ExecContext(ctx context.Context, query string, args ...any) (res sql.Result, err error) {
+	res, violation := observe(ctx, query, false, func() sql.Result {
+		res, err = o.db.ExecContext(ctx, query, args...)
+		return res
+	}, nil)
+	return res, cmp.Or(err, violation)
+}
+
+// Close closes the wrapped database
+func (o *Observed) Close() error {
+	return o.db.Close()
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserved(t *testing.T) {
+	raw, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer raw.Close()
+	raw.SetMaxOpenConns(1)
+
+	_, err = raw.Exec("CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, price REAL)")
+	require.NoError(t, err)
+
+	db := NewObserved(raw)
+	repo := NewRepository[*schema, TestProduct](&schema{})
+
+	t.Run("Counts queries", func(t *testing.T) {
+		ctx, stats := WithQueryStats(WithDB(context.Background(), db), ObserverConfig{})
+
+		p, err := repo.Create(ctx, TestProduct{Name: "Widget", Price: 10})
+		require.NoError(t, err)
+		_, err = repo.FindByID(ctx, p.ID)
+		require.NoError(t, err)
+
+		assert.GreaterOrEqual(t, stats.Count(), 2)
+		assert.Positive(t, stats.Duration())
+	})
+
+	t.Run("Counts queries in transactions", func(t *testing.T) {
+		ctx, stats := WithQueryStats(WithDB(context.Background(), db), ObserverConfig{})
+
+		err := WithTransaction(ctx, func(ctx context.Context) error {
+			_, err := repo.Select().Execute(ctx)
+			return err
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, stats.Count())
+	})
+
+	t.Run("Detects N+1 queries in strict mode", func(t *testing.T) {
+		ctx, stats := WithQueryStats(WithDB(context.Background(), db), ObserverConfig{RepeatThreshold: 3, Strict: true})
+
+		for range 2 {
+			_, err := repo.FindByID(ctx, 1)
+			require.NoError(t, err)
+		}
+		require.NoError(t, stats.Err())
+
+		// The row is still read, the violation is recorded for the request
+		_, err := repo.FindByID(ctx, 1)
+		require.NoError(t, err)
+
+		var violation *QueryViolationError
+		require.ErrorAs(t, stats.Err(), &violation)
+		assert.Equal(t, "n+1", violation.Kind)
+		assert.Equal(t, 3, violation.Count)
+	})
+
+	t.Run("Detects slow queries in strict mode", func(t *testing.T) {
+		ctx, stats := WithQueryStats(WithDB(context.Background(), db), ObserverConfig{SlowThreshold: time.Nanosecond, Strict: true})
+
+		_, err := repo.Select().Execute(ctx)
+		var violation *QueryViolationError
+		require.ErrorAs(t, err, &violation)
+		assert.Equal(t, "slow", violation.Kind)
+		assert.Equal(t, violation, stats.Err())
+
+		// The connection was not leaked by the released rows
+		_, err = repo.Select().Execute(WithDB(context.Background(), db))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Only warns outside strict mode", func(t *testing.T) {
+		ctx, stats := WithQueryStats(WithDB(context.Background(), db), ObserverConfig{RepeatThreshold: 2, SlowThreshold: time.Nanosecond})
+
+		for range 3 {
+			_, err := repo.FindByID(ctx, 1)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 3, stats.Count())
+	})
+}
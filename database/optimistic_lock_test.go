@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Account struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	VersionedModel
+}
+
+func (a *Account) TableName() string { return "accounts" }
+func (a *Account) Fields() []string  { return []string{"name", "version"} }
+func (a *Account) Values() []any     { return []any{a.Name, a.Version} }
+func (a *Account) Scan(ctx context.Context, schema any, row Scanner) (*Account, error) {
+	var res Account
+	err := row.Scan(&res.ID, &res.Name, &res.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+func (a *Account) HasAutoIncrementID() bool { return true }
+func (a *Account) GetID() any               { return a.ID }
+
+func TestOptimisticLocking(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE accounts (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, version INTEGER NOT NULL DEFAULT 0)")
+	require.NoError(t, err)
+
+	ctx := WithDB(context.Background(), db)
+	repo := NewRepository[any, *Account](nil)
+
+	created, err := repo.Create(ctx, &Account{Name: "original"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, created.Version)
+
+	first, err := repo.FindByID(ctx, created.ID)
+	require.NoError(t, err)
+	second, err := repo.FindByID(ctx, created.ID)
+	require.NoError(t, err)
+
+	t.Run("Update increments version", func(t *testing.T) {
+		first.Name = "first"
+		require.NoError(t, repo.Update(ctx, first))
+		assert.Equal(t, 1, first.Version)
+
+		found, err := repo.FindByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", found.Name)
+		assert.Equal(t, 1, found.Version)
+	})
+
+	t.Run("Stale update is rejected", func(t *testing.T) {
+		second.Name = "second"
+		err := repo.Update(ctx, second)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrStaleObject))
+
+		var stale *StaleObjectError
+		require.True(t, errors.As(err, &stale))
+		assert.Equal(t, "accounts", stale.Table)
+		assert.Equal(t, 0, stale.Version)
+		assert.Equal(t, 0, second.Version)
+
+		found, err := repo.FindByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", found.Name)
+	})
+}
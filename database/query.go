@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"iter"
 	"strings"
 
 	"go.opentelemetry.io/otel"
@@ -14,6 +15,13 @@ type WhereableQuery[S Schema, T Model[S, T]] interface {
 	OrderableQuery[S, T]
 
 	Where(string, ...any) WhereableQuery[S, T]
+	// WhereJSON filters on the value at a path in a JSON column
+	WhereJSON(column, path string, value any) WhereableQuery[S, T]
+
+	// UpdateWhere sets the given columns on all matching records
+	UpdateWhere(ctx context.Context, values map[string]any) (int64, error)
+	// DeleteWhere removes all matching records
+	DeleteWhere(ctx context.Context) (int64, error)
 }
 
 type OrderDirection byte
@@ -34,10 +42,18 @@ const (
 	DESC
 )
 
+type orderColumn struct {
+	name      string
+	direction OrderDirection
+}
+
 type OrderableQuery[S Schema, T Model[S, T]] interface {
 	LimitableQuery[S, T]
 
 	Order(string, OrderDirection) OrderableQuery[S, T]
+
+	// Paginate runs the query with keyset pagination, starting after the cursor
+	Paginate(ctx context.Context, cursor string, size int) (*CursorPage[T], error)
 }
 
 type LimitableQuery[S Schema, T Model[S, T]] interface {
@@ -59,6 +75,8 @@ type ExecutableQuery[S Schema, T Model[S, T]] interface {
 	Execute(ctx context.Context) ([]T, error)
 	First(ctx context.Context) (T, error)
 	Count(ctx context.Context) (int, error)
+	// Iter runs the query and streams the results
+	Iter(ctx context.Context) iter.Seq2[T, error]
 }
 
 // Query is the base interface for all query types
@@ -75,10 +93,12 @@ type QueryBuilder[S Schema, T Model[S, T]] struct {
 	conditions []string
 	args       []any
 	orderBy    []string
-	limit      int
-	offset     int
-	hasLimit   bool
-	hasOffset  bool
+	// orderColumns keeps the ordering in a structured form for keyset pagination
+	orderColumns []orderColumn
+	limit        int
+	offset       int
+	hasLimit     bool
+	hasOffset    bool
 }
 
 // Where adds WHERE conditions to the query
@@ -88,9 +108,16 @@ func (q *QueryBuilder[S, T]) Where(condition string, args ...any) WhereableQuery
 	return q
 }
 
+// WhereJSON adds a condition that the value at path in the JSON column equals value, e.g.
+// WhereJSON("settings", "plan", "pro"). See JSONExtract for the path syntax.
+func (q *QueryBuilder[S, T]) WhereJSON(column, path string, value any) WhereableQuery[S, T] {
+	return q.Where(fmt.Sprintf("json_extract(%s, ?) = ?", column), jsonPath(path), value)
+}
+
 // Order adds ORDER BY clause to the query
 func (q *QueryBuilder[S, T]) Order(orderBy string, direction OrderDirection) OrderableQuery[S, T] {
 	q.orderBy = append(q.orderBy, orderBy+" "+direction.String())
+	q.orderColumns = append(q.orderColumns, orderColumn{name: orderBy, direction: direction})
 	return q
 }
 
@@ -125,8 +152,28 @@ func (q *QueryBuilder[S, T]) Build(ctx context.Context) (string, []any) {
 
 	query := "SELECT " + fields + " FROM " + q.tableName
 
-	conditions := q.conditions
-	args := q.args
+	where, args := q.where(ctx)
+	query += where
+
+	if len(q.orderBy) > 0 {
+		query += " ORDER BY " + strings.Join(q.orderBy, ", ")
+	}
+
+	if q.hasLimit {
+		query += " LIMIT " + fmt.Sprintf("%d", q.limit)
+	}
+
+	if q.hasOffset {
+		query += " OFFSET " + fmt.Sprintf("%d", q.offset)
+	}
+
+	return query, args
+}
+
+// where constructs the WHERE clause, including the implicit domain and soft-delete conditions
+func (q *QueryBuilder[S, T]) where(ctx context.Context) (string, []any) {
+	conditions := append([]string(nil), q.conditions...)
+	args := append([]any(nil), q.args...)
 
 	// Domain-aware scoping
 	enabled := IsDomainFilteringEnabled(ctx)
@@ -143,23 +190,15 @@ func (q *QueryBuilder[S, T]) Build(ctx context.Context) (string, []any) {
 		}
 	}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	// Exclude soft-deleted records
+	if _, ok := any(q.repo.zero).(SoftDeletable); ok && !shouldIncludeDeleted(ctx) {
+		conditions = append(conditions, "deleted_at IS NULL")
 	}
 
-	if len(q.orderBy) > 0 {
-		query += " ORDER BY " + strings.Join(q.orderBy, ", ")
+	if len(conditions) == 0 {
+		return "", args
 	}
-
-	if q.hasLimit {
-		query += " LIMIT " + fmt.Sprintf("%d", q.limit)
-	}
-
-	if q.hasOffset {
-		query += " OFFSET " + fmt.Sprintf("%d", q.offset)
-	}
-
-	return query, args
+	return " WHERE " + strings.Join(conditions, " AND "), args
 }
 
 // Execute runs the query and returns the results
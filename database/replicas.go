@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+)
+
+// Replicated is a Database that sends reads to read replicas and writes to the primary.
+// Transactions always run on the primary, so reads within a transaction see its writes.
+type Replicated struct {
+	primary  Database
+	replicas []Database
+	next     atomic.Uint64
+}
+
+// Ensure Replicated implements Database
+var _ Database = (*Replicated)(nil)
+
+// NewReplicated creates a Database that balances reads over the replicas in a round-robin
+// fashion. Without replicas, all queries go to the primary.
+func NewReplicated(primary Database, replicas ...Database) *Replicated {
+	return &Replicated{
+		primary:  primary,
+		replicas: replicas,
+	}
+}
+
+// Primary returns the database that receives the writes
+func (r *Replicated) Primary() Database {
+	return r.primary
+}
+
+// Replicas returns the read replicas
+func (r *Replicated) Replicas() []Database {
+	return r.replicas
+}
+
+func (r *Replicated) reader(ctx context.Context) Database {
+	if len(r.replicas) == 0 || shouldUsePrimary(ctx) {
+		return r.primary
+	}
+	return r.replicas[r.next.Add(1)%uint64(len(r.replicas))]
+}
+
+// QueryContext executes a query that returns rows on one of the replicas
+func (r *Replicated) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return r.reader(ctx).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext executes a query that returns a single row on one of the replicas
+func (r *Replicated) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return r.reader(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// ExecContext executes a query that doesn't return rows on the primary
+func (r *Replicated) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	markWritten(ctx)
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+// Close closes the primary and all replicas
+func (r *Replicated) Close() error {
+	errs := []error{r.primary.Close()}
+	for _, replica := range r.replicas {
+		errs = append(errs, replica.Close())
+	}
+	return errors.Join(errs...)
+}
+
+type usePrimaryKey struct{}
+
+// UsePrimary returns a new context that sends reads to the primary database, for example to
+// read records that were just written before the replicas caught up.
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, usePrimaryKey{}, true)
+}
+
+type readYourWritesKey struct{}
+
+// WithReadYourWrites returns a new context that sends reads to the primary database once a write
+// or a transaction ran with it, so they see the writes even when the replicas lag behind. The
+// router does this for every request.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readYourWritesKey{}, new(atomic.Bool))
+}
+
+// markWritten sends the remaining reads of a context created with WithReadYourWrites to the primary
+func markWritten(ctx context.Context) {
+	if written, ok := ctx.Value(readYourWritesKey{}).(*atomic.Bool); ok {
+		written.Store(true)
+	}
+}
+
+func shouldUsePrimary(ctx context.Context) bool {
+	if use, _ := ctx.Value(usePrimaryKey{}).(bool); use {
+		return true
+	}
+	written, _ := ctx.Value(readYourWritesKey{}).(*atomic.Bool)
+	return written != nil && written.Load()
+}
+
+// sqlDB returns the *sql.DB that transactions and migrations run on, looking through
+// databases that wrap a primary.
+func sqlDB(db Database) (*sql.DB, bool) {
+	for {
+		switch d := db.(type) {
+		case *sql.DB:
+			return d, true
+		case interface{ Primary() Database }:
+			db = d.Primary()
+		default:
+			return nil, false
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicated(t *testing.T) {
+	open := func(name string) *sql.DB {
+		db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), name))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		_, err = db.Exec("CREATE TABLE test (id INTEGER PRIMARY KEY, value TEXT)")
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO test (value) VALUES (?)", name)
+		require.NoError(t, err)
+		return db
+	}
+
+	primary := open("primary")
+	replica := open("replica")
+
+	db := NewReplicated(primary, replica)
+	ctx := WithDB(context.Background(), db)
+
+	value := func(ctx context.Context) string {
+		var v string
+		require.NoError(t, FromContext(ctx).QueryRowContext(ctx, "SELECT value FROM test WHERE id = 1").Scan(&v))
+		return v
+	}
+
+	t.Run("Reads go to the replica", func(t *testing.T) {
+		assert.Equal(t, "replica", value(ctx))
+		assert.Equal(t, "primary", value(UsePrimary(ctx)))
+	})
+
+	t.Run("Writes go to the primary", func(t *testing.T) {
+		_, err := db.ExecContext(ctx, "UPDATE test SET value = ? WHERE id = 1", "written")
+		require.NoError(t, err)
+
+		assert.Equal(t, "written", value(UsePrimary(ctx)))
+		assert.Equal(t, "replica", value(ctx))
+	})
+
+	t.Run("Transactions run on the primary", func(t *testing.T) {
+		err := WithTransaction(ctx, func(txCtx context.Context) error {
+			assert.Equal(t, "written", value(txCtx))
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Migrations run on the primary", func(t *testing.T) {
+		raw, ok := sqlDB(db)
+		assert.True(t, ok)
+		assert.Same(t, primary, raw)
+	})
+}
+
+func TestReplicatedLag(t *testing.T) {
+	open := func(name string) *sql.DB {
+		db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), name))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		_, err = db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY AUTOINCREMENT, sku TEXT, stock INTEGER, domain TEXT)")
+		require.NoError(t, err)
+		return db
+	}
+
+	// The replica never receives the writes of the primary
+	db := NewReplicated(open("primary"), open("replica"))
+	ctx := WithDB(context.Background(), db)
+	repo := NewRepository[any, *Item](nil)
+
+	t.Run("Writes read back from the primary", func(t *testing.T) {
+		created, err := repo.Create(ctx, &Item{SKU: "a", Stock: 1})
+		require.NoError(t, err)
+		require.NotNil(t, created)
+		assert.Equal(t, "a", created.SKU)
+
+		created.Stock = 2
+		require.NoError(t, repo.Update(ctx, created))
+
+		found, err := repo.FindByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found, "reads without a write go to the replica")
+	})
+
+	t.Run("Reads follow the writes of a context", func(t *testing.T) {
+		ctx := WithReadYourWrites(ctx)
+
+		created, err := repo.Create(ctx, &Item{SKU: "b", Stock: 1})
+		require.NoError(t, err)
+
+		found, err := repo.FindByID(ctx, created.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, "b", found.SKU)
+	})
+}
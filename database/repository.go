@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -148,53 +149,24 @@ func (r *Repository[S, T]) Create(ctx context.Context, model T) (T, error) {
 	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "repository.create", trace.WithAttributes(attribute.String("table", r.zero.TableName())))
 	defer span.End()
 
-	if !shouldSkipHooks(ctx) {
-		if h, ok := any(model).(BeforeCreateHook); ok {
-			if err := h.BeforeCreate(ctx); err != nil {
-				return r.zero, err
-			}
-		}
-	}
-
-	// Domain-aware scoping
-	if IsDomainFilteringEnabled(ctx) && !shouldSkipDomainScope(ctx) {
-		if ds, ok := any(model).(DomainScoped); ok {
-			domain := DomainFromContext(ctx)
-			if domain != "" && ds.GetDomain() == "" {
-				ds.SetDomain(domain)
-			}
+	// Writes read from the primary, the replicas may not have the records yet
+	ctx = UsePrimary(ctx)
 
-			// Unique per domain validation
-			rv := reflect.ValueOf(model)
-			if rv.Kind() == reflect.Ptr {
-				rv = rv.Elem()
-			}
-			rt := rv.Type()
-			for i := 0; i < rt.NumField(); i++ {
-				field := rt.Field(i)
-				if strings.Contains(field.Tag.Get("validate"), "unique_per_domain") {
-					fieldName := strings.ToLower(field.Name) // Simple mapping, could be more robust
-					// Try to find the DB field name from Fields() or struct tags
-					// For now, assume field name is the same as DB column name
-					exists, err := r.Exists(ctx, map[string]any{fieldName: rv.Field(i).Interface()})
-					if err != nil {
-						return r.zero, err
-					}
-					if exists {
-						return r.zero, ErrDuplicate
-					}
-				}
-			}
-		}
+	if _, ok := any(model).(Audited); ok {
+		return r.createAudited(ctx, model)
 	}
+	return r.create(ctx, model)
+}
 
-	// GetFunc all fields and values
-	fields := model.Fields()
-	values := model.Values()
+// create inserts a new record, without recording it in the audit log
+func (r *Repository[S, T]) create(ctx context.Context, model T) (T, error) {
+	if err := r.prepareCreate(ctx, model); err != nil {
+		return r.zero, err
+	}
 
-	if !model.HasAutoIncrementID() {
-		fields = append([]string{"id"}, fields...)
-		values = append([]any{model.GetID()}, values...)
+	fields, values := r.insertColumns(model)
+	if hasExplicitID(ctx, model) {
+		fields, values = append([]string{"id"}, fields...), append([]any{model.GetID()}, values...)
 	}
 
 	// Domain-aware scoping
@@ -255,11 +227,111 @@ func (r *Repository[S, T]) Create(ctx context.Context, model T) (T, error) {
 	return created, nil
 }
 
+// prepareCreate runs the before create hooks, fills the timestamps and applies the
+// domain scoping to a model that is about to be inserted.
+func (r *Repository[S, T]) prepareCreate(ctx context.Context, model T) error {
+	if err := r.beforeCreate(ctx, model); err != nil {
+		return err
+	}
+	return r.validateCreate(ctx, model)
+}
+
+// beforeCreate runs the before create hooks, fills the timestamps and sets the domain of a model
+// that is about to be inserted
+func (r *Repository[S, T]) beforeCreate(ctx context.Context, model T) error {
+	if !shouldSkipHooks(ctx) {
+		if h, ok := any(model).(BeforeCreateHook); ok {
+			if err := h.BeforeCreate(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ts, ok := any(model).(Timestamped); ok {
+		now := time.Now()
+		if ts.GetCreatedAt().IsZero() {
+			ts.SetCreatedAt(now)
+		}
+		if ts.GetUpdatedAt().IsZero() {
+			ts.SetUpdatedAt(now)
+		}
+	}
+
+	setDomain(ctx, model)
+	return nil
+}
+
+// validateCreate checks the unique_per_domain fields of a domain scoped model that is about to be
+// inserted
+func (r *Repository[S, T]) validateCreate(ctx context.Context, model T) error {
+	if IsDomainFilteringEnabled(ctx) && !shouldSkipDomainScope(ctx) {
+		if _, ok := any(model).(DomainScoped); ok {
+
+			// Unique per domain validation
+			rv := reflect.ValueOf(model)
+			if rv.Kind() == reflect.Ptr {
+				rv = rv.Elem()
+			}
+			rt := rv.Type()
+			for i := 0; i < rt.NumField(); i++ {
+				field := rt.Field(i)
+				if strings.Contains(field.Tag.Get("validate"), "unique_per_domain") {
+					fieldName := strings.ToLower(field.Name) // Simple mapping, could be more robust
+					// Try to find the DB field name from Fields() or struct tags
+					// For now, assume field name is the same as DB column name
+					exists, err := r.Exists(ctx, map[string]any{fieldName: rv.Field(i).Interface()})
+					if err != nil {
+						return err
+					}
+					if exists {
+						return ErrDuplicate
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// setDomain sets the domain of the context on a domain scoped model that has none yet
+func setDomain(ctx context.Context, model any) {
+	if !IsDomainFilteringEnabled(ctx) || shouldSkipDomainScope(ctx) {
+		return
+	}
+	if ds, ok := model.(DomainScoped); ok {
+		if domain := DomainFromContext(ctx); domain != "" && ds.GetDomain() == "" {
+			ds.SetDomain(domain)
+		}
+	}
+}
+
+// insertColumns returns the columns and values to insert for the given model
+func (r *Repository[S, T]) insertColumns(model T) ([]string, []any) {
+	fields := model.Fields()
+	values := model.Values()
+
+	if !model.HasAutoIncrementID() {
+		fields = append([]string{"id"}, fields...)
+		values = append([]any{model.GetID()}, values...)
+	}
+	return fields, values
+}
+
 // Update updates an existing record in the database
 func (r *Repository[S, T]) Update(ctx context.Context, model T) error {
 	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "repository.update")
 	defer span.End()
+	ctx = UsePrimary(ctx)
 
+	if _, ok := any(model).(Audited); ok {
+		return r.updateAudited(ctx, model, AuditUpdate)
+	}
+	return r.update(ctx, model)
+}
+
+// update updates an existing record, without recording it in the audit log
+func (r *Repository[S, T]) update(ctx context.Context, model T) error {
 	if !shouldSkipHooks(ctx) {
 		if h, ok := any(model).(BeforeUpdateHook); ok {
 			if err := h.BeforeUpdate(ctx); err != nil {
@@ -268,6 +340,10 @@ func (r *Repository[S, T]) Update(ctx context.Context, model T) error {
 		}
 	}
 
+	if ts, ok := any(model).(Timestamped); ok {
+		ts.SetUpdatedAt(time.Now())
+	}
+
 	fields := model.Fields()
 	values := model.Values()
 
@@ -275,11 +351,24 @@ func (r *Repository[S, T]) Update(ctx context.Context, model T) error {
 	var setClause []string
 	var args []any
 
+	versioned, isVersioned := any(model).(Versioned)
+
 	for i, field := range fields {
+		// The version column is managed by the repository for versioned models
+		if isVersioned && field == "version" {
+			continue
+		}
 		setClause = append(setClause, fmt.Sprintf("%s = ?", field))
 		args = append(args, values[i])
 	}
 
+	var version int
+	if isVersioned {
+		version = versioned.GetVersion()
+		setClause = append(setClause, "version = ?")
+		args = append(args, version+1)
+	}
+
 	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = ?",
 		model.TableName(),
 		strings.Join(setClause, ", "))
@@ -298,11 +387,28 @@ func (r *Repository[S, T]) Update(ctx context.Context, model T) error {
 		}
 	}
 
-	_, err := FromContext(ctx).ExecContext(ctx, query, args...)
+	// Optimistic locking
+	if isVersioned {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+
+	res, err := FromContext(ctx).ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
 
+	if isVersioned {
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return &StaleObjectError{Table: model.TableName(), ID: model.GetID(), Version: version}
+		}
+		versioned.SetVersion(version + 1)
+	}
+
 	if !shouldSkipHooks(ctx) {
 		if h, ok := any(model).(AfterUpdateHook); ok {
 			if err := h.AfterUpdate(ctx); err != nil {
@@ -318,7 +424,16 @@ func (r *Repository[S, T]) Update(ctx context.Context, model T) error {
 func (r *Repository[S, T]) Delete(ctx context.Context, model T) error {
 	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "repository.delete")
 	defer span.End()
+	ctx = UsePrimary(ctx)
 
+	if _, ok := any(model).(Audited); ok {
+		return r.deleteAudited(ctx, model)
+	}
+	return r.delete(ctx, model)
+}
+
+// delete removes a record, without recording it in the audit log
+func (r *Repository[S, T]) delete(ctx context.Context, model T) error {
 	if !shouldSkipHooks(ctx) {
 		if h, ok := any(model).(BeforeDeleteHook); ok {
 			if err := h.BeforeDelete(ctx); err != nil {
@@ -330,6 +445,15 @@ func (r *Repository[S, T]) Delete(ctx context.Context, model T) error {
 	query := fmt.Sprintf("DELETE FROM %s WHERE id = ?", model.TableName())
 	args := []any{model.GetID()}
 
+	// Soft-deletable models only get their deleted_at column set
+	sd, softDelete := any(model).(SoftDeletable)
+	var deletedAt time.Time
+	if softDelete {
+		deletedAt = time.Now()
+		query = fmt.Sprintf("UPDATE %s SET deleted_at = ? WHERE id = ?", model.TableName())
+		args = []any{deletedAt, model.GetID()}
+	}
+
 	// Domain-aware scoping
 	if IsDomainFilteringEnabled(ctx) && !shouldSkipDomainScope(ctx) {
 		if _, ok := any(model).(DomainScoped); ok {
@@ -346,6 +470,10 @@ func (r *Repository[S, T]) Delete(ctx context.Context, model T) error {
 		return err
 	}
 
+	if softDelete {
+		sd.SetDeletedAt(&deletedAt)
+	}
+
 	if !shouldSkipHooks(ctx) {
 		if h, ok := any(model).(AfterDeleteHook); ok {
 			if err := h.AfterDelete(ctx); err != nil {
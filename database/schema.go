@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+// ErrSchemaNotEmpty is returned when a schema is loaded into a database that already has tables
+var ErrSchemaNotEmpty = errors.New("database already contains a schema")
+
+// DumpSchema writes the schema of the database as SQL statements to w, followed by the applied
+// migration versions of the application and the framework. The output is canonical: tables,
+// indexes, views and triggers are sorted by type and name, so the dump only changes when the
+// schema does. The shadow tables of virtual tables, like the FTS5 search indexes, are left out as
+// they are created along with the virtual table.
+func DumpSchema(ctx context.Context, db Database, dbType Type, w io.Writer) error {
+	rows, err := db.QueryContext(ctx, `
+		SELECT sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+			AND name NOT IN (SELECT name FROM pragma_table_list WHERE schema = 'main' AND type = 'shadow')
+		ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'index' THEN 1 WHEN 'view' THEN 2 ELSE 3 END, name`)
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %w", err)
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return err
+		}
+		statements = append(statements, strings.TrimSpace(stmt)+";")
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "-- Schema of the %s database, generated by `tracks db schema:dump`. Do not edit.\n", dbType)
+	for _, stmt := range statements {
+		fmt.Fprintf(w, "\n%s\n", stmt)
+	}
+
+	for _, table := range []string{goose.TableName(), frameworkVersionTable} {
+		versions, err := appliedVersions(ctx, db, table)
+		if err != nil {
+			return err
+		}
+		if len(versions) > 0 {
+			fmt.Fprintln(w)
+			for _, v := range versions {
+				fmt.Fprintf(w, "INSERT INTO %s (version_id, is_applied) VALUES (%d, 1);\n", table, v)
+			}
+		}
+	}
+	return nil
+}
+
+// appliedVersions returns the migration versions in the goose version table that are currently
+// applied, in order. It returns no versions when the database has never been migrated.
+func appliedVersions(ctx context.Context, db Database, table string) ([]int64, error) {
+	var exists int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration versions: %w", err)
+	}
+	if exists == 0 {
+		return nil, nil
+	}
+
+	// Only the most recent entry of each version tells whether it is still applied
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT version_id FROM %[1]s
+		WHERE id IN (SELECT MAX(id) FROM %[1]s GROUP BY version_id) AND is_applied
+		ORDER BY version_id`, table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []int64
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// LoadSchema creates the schema written by DumpSchema in an empty database, in a single
+// transaction. Loading a schema is faster than running all migrations on a new database, and
+// migrations added afterwards are applied on top of it.
+func LoadSchema(ctx context.Context, db Database, r io.Reader) error {
+	var tables int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'").Scan(&tables)
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %w", err)
+	}
+	if tables > 0 {
+		return ErrSchemaNotEmpty
+	}
+
+	schema, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return WithTransaction(WithDB(ctx, db), func(ctx context.Context) error {
+		if _, err := FromContext(ctx).ExecContext(ctx, string(schema)); err != nil {
+			return fmt.Errorf("failed to load schema: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeMigrations(t *testing.T, dir string) {
+	t.Helper()
+
+	migrations := map[string]string{
+		"20260101000000_create_products.sql": "CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT);",
+		"20260102000000_index_products.sql":  "CREATE INDEX idx_products_name ON products (name);",
+		"20260103000000_create_orders.sql":   "CREATE TABLE orders (id INTEGER PRIMARY KEY, product_id INTEGER);",
+	}
+	down := map[string]string{
+		"20260101000000_create_products.sql": "DROP TABLE products;",
+		"20260102000000_index_products.sql":  "DROP INDEX idx_products_name;",
+		"20260103000000_create_orders.sql":   "DROP TABLE orders;",
+	}
+
+	path := filepath.Join(dir, "migrations", "central")
+	require.NoError(t, os.MkdirAll(path, 0755))
+	for name, up := range migrations {
+		content := "-- +goose Up\n" + up + "\n\n-- +goose Down\n" + down[name] + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(path, name), []byte(content), 0644))
+	}
+}
+
+func TestSchemaDumpAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeMigrations(t, dir)
+	ctx := context.Background()
+
+	source, err := sql.Open("sqlite3", filepath.Join(dir, "source.sqlite"))
+	require.NoError(t, err)
+	defer source.Close()
+	require.NoError(t, MigrateUpDir(ctx, source, CentralDatabase, filepath.Join(dir, "migrations", "central")))
+
+	var dump bytes.Buffer
+	require.NoError(t, DumpSchema(ctx, source, CentralDatabase, &dump))
+
+	schema := dump.String()
+	assert.Less(t, strings.Index(schema, "CREATE TABLE orders"), strings.Index(schema, "CREATE TABLE products"))
+	assert.Less(t, strings.Index(schema, "CREATE TABLE products"), strings.Index(schema, "CREATE INDEX idx_products_name"))
+	assert.Contains(t, schema, "VALUES (20260103000000, 1);")
+
+	t.Run("Dump is canonical", func(t *testing.T) {
+		var again bytes.Buffer
+		require.NoError(t, DumpSchema(ctx, source, CentralDatabase, &again))
+		assert.Equal(t, schema, again.String())
+	})
+
+	t.Run("Load creates the schema and versions", func(t *testing.T) {
+		target, err := sql.Open("sqlite3", filepath.Join(dir, "target.sqlite"))
+		require.NoError(t, err)
+		defer target.Close()
+
+		require.NoError(t, LoadSchema(ctx, target, strings.NewReader(schema)))
+
+		var loaded bytes.Buffer
+		require.NoError(t, DumpSchema(ctx, target, CentralDatabase, &loaded))
+		assert.Equal(t, schema, loaded.String())
+
+		// Migrations treat the loaded database as up to date
+		require.NoError(t, MigrateUpDir(ctx, target, CentralDatabase, filepath.Join(dir, "migrations", "central")))
+	})
+
+	t.Run("Load refuses a database with tables", func(t *testing.T) {
+		assert.ErrorIs(t, LoadSchema(ctx, source, strings.NewReader(schema)), ErrSchemaNotEmpty)
+	})
+}
+
+func TestRollbackMigrations(t *testing.T) {
+	dir := t.TempDir()
+	writeMigrations(t, dir)
+	t.Chdir(dir)
+	ctx := context.Background()
+
+	dbPath := filepath.Join(dir, "tracks.sqlite")
+	require.NoError(t, RunGooseMigration(ctx, "up", CentralDatabase, dbPath))
+
+	tableExists := func(name string) bool {
+		db, err := sql.Open("sqlite3", dbPath)
+		require.NoError(t, err)
+		defer db.Close()
+
+		var n int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = ?", name).Scan(&n))
+		return n > 0
+	}
+
+	require.NoError(t, RollbackMigrations(ctx, CentralDatabase, dbPath, 2))
+	assert.False(t, tableExists("orders"))
+	assert.False(t, tableExists("idx_products_name"))
+	assert.True(t, tableExists("products"))
+
+	require.NoError(t, RunGooseMigration(ctx, "redo", CentralDatabase, dbPath))
+	assert.True(t, tableExists("products"))
+
+	// Rolling back more steps than applied stops at the first migration
+	require.NoError(t, RollbackMigrations(ctx, CentralDatabase, dbPath, 5))
+	assert.False(t, tableExists("products"))
+
+	assert.Error(t, RollbackMigrations(ctx, CentralDatabase, dbPath, 0))
+}
+
+func TestSchemaDumpSearchIndex(t *testing.T) {
+	ctx := context.Background()
+
+	source, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer source.Close()
+	source.SetMaxOpenConns(1)
+
+	var fts5 int
+	require.NoError(t, source.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5))
+	if fts5 == 0 {
+		t.Skip("SQLite was built without FTS5, run the tests with -tags sqlite_fts5")
+	}
+
+	up, _ := SearchIndexSQL("articles", []string{"title", "body"})
+	_, err = source.Exec("CREATE TABLE articles (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT, body TEXT, domain TEXT, deleted_at TIMESTAMP);\n" + up)
+	require.NoError(t, err)
+
+	var dump bytes.Buffer
+	require.NoError(t, DumpSchema(ctx, source, CentralDatabase, &dump))
+	schema := dump.String()
+	assert.Contains(t, schema, "CREATE VIRTUAL TABLE articles_search")
+	assert.NotContains(t, schema, "articles_search_data")
+
+	target, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer target.Close()
+	target.SetMaxOpenConns(1)
+
+	require.NoError(t, LoadSchema(ctx, target, strings.NewReader(schema)))
+
+	var loaded bytes.Buffer
+	require.NoError(t, DumpSchema(ctx, target, CentralDatabase, &loaded))
+	assert.Equal(t, schema, loaded.String())
+
+	// The loaded index is kept in sync with the table
+	tctx := WithDB(ctx, target)
+	repo := NewRepository[any, *Article](nil)
+	_, err = repo.Create(tctx, &Article{Title: "Getting started", Body: "Install tracks"})
+	require.NoError(t, err)
+	results, err := repo.Search(tctx, "install")
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
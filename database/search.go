@@ -0,0 +1,271 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrNotSearchable is returned when a repository is searched whose model doesn't implement Searchable
+var ErrNotSearchable = errors.New("model is not searchable")
+
+// Searchable is the interface that models implement to enable full-text search on some of their
+// columns. The columns are indexed in an FTS5 table named <table>_search, which is kept in sync
+// with the model table by triggers. Encrypted columns can't be searched.
+//
+// FTS5 is only available when the application is built with the sqlite_fts5 build tag.
+type Searchable interface {
+	SearchFields() []string
+}
+
+// Markers around the matched terms in snippets, replaced by <mark> tags after escaping the text
+const (
+	searchMatchStart = "\x02"
+	searchMatchEnd   = "\x03"
+)
+
+// SearchOptions configures a full-text search
+type SearchOptions struct {
+	// Limit is the maximum number of results, all results are returned when it's 0
+	Limit int
+	// Offset is the number of results to skip
+	Offset int
+	// SnippetTokens is the maximum number of tokens in a snippet, 16 by default
+	SnippetTokens int
+}
+
+// SearchResult is a record that matched a full-text search
+type SearchResult[T any] struct {
+	Record T
+	// Rank is the bm25 score of the match, lower scores are better matches
+	Rank float64
+	// Snippet is the fragment of the best matching column, with the matched terms in <mark> tags
+	Snippet template.HTML
+	// Highlights contains the full text of each searchable column, with the matched terms in
+	// <mark> tags
+	Highlights map[string]template.HTML
+}
+
+// SearchIndexSQL returns the statements that create and drop the FTS5 table and the triggers that
+// keep it in sync with the table, to be used in a migration. Creating the index in a migration
+// doesn't index existing records, run CreateSearchIndex on the repository once for those.
+func SearchIndexSQL(table string, fields []string) (up string, down string) {
+	index := table + "_search"
+	columns := strings.Join(fields, ", ")
+	prefixed := func(prefix string) string {
+		values := make([]string, len(fields))
+		for i, f := range fields {
+			values[i] = prefix + "." + f
+		}
+		return strings.Join(values, ", ")
+	}
+
+	up = fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS %[1]s USING fts5(%[3]s, content='%[2]s', content_rowid='rowid');
+CREATE TRIGGER IF NOT EXISTS %[1]s_insert AFTER INSERT ON %[2]s BEGIN
+	INSERT INTO %[1]s(rowid, %[3]s) VALUES (new.rowid, %[4]s);
+END;
+CREATE TRIGGER IF NOT EXISTS %[1]s_delete AFTER DELETE ON %[2]s BEGIN
+	INSERT INTO %[1]s(%[1]s, rowid, %[3]s) VALUES ('delete', old.rowid, %[5]s);
+END;
+CREATE TRIGGER IF NOT EXISTS %[1]s_update AFTER UPDATE ON %[2]s BEGIN
+	INSERT INTO %[1]s(%[1]s, rowid, %[3]s) VALUES ('delete', old.rowid, %[5]s);
+	INSERT INTO %[1]s(rowid, %[3]s) VALUES (new.rowid, %[4]s);
+END;`, index, table, columns, prefixed("new"), prefixed("old"))
+
+	down = fmt.Sprintf(`DROP TRIGGER IF EXISTS %[1]s_insert;
+DROP TRIGGER IF EXISTS %[1]s_delete;
+DROP TRIGGER IF EXISTS %[1]s_update;
+DROP TABLE IF EXISTS %[1]s;`, index)
+
+	return up, down
+}
+
+// searchFields returns the searchable columns of the model, after checking they are columns of
+// the model
+func (r *Repository[S, T]) searchFields() ([]string, error) {
+	s, ok := any(r.zero).(Searchable)
+	if !ok {
+		return nil, ErrNotSearchable
+	}
+
+	fields := s.SearchFields()
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: %s has no search fields", ErrNotSearchable, r.zero.TableName())
+	}
+	for _, f := range fields {
+		if !slices.Contains(r.zero.Fields(), f) {
+			return nil, fmt.Errorf("search field %s is not a column of %s", f, r.zero.TableName())
+		}
+	}
+	return fields, nil
+}
+
+// CreateSearchIndex creates the FTS5 table and triggers for the searchable columns of the model if
+// they don't exist yet, and indexes all existing records.
+func (r *Repository[S, T]) CreateSearchIndex(ctx context.Context) error {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "repository.createsearchindex", trace.WithAttributes(attribute.String("table", r.zero.TableName())))
+	defer span.End()
+
+	fields, err := r.searchFields()
+	if err != nil {
+		return err
+	}
+
+	table := r.zero.TableName()
+	up, _ := SearchIndexSQL(table, fields)
+
+	return WithTransaction(ctx, func(ctx context.Context) error {
+		db := FromContext(ctx)
+		if _, err := db.ExecContext(ctx, up); err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("failed to create search index: %w", err)
+		}
+
+		index := table + "_search"
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %[1]s(%[1]s) VALUES ('rebuild')", index)); err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("failed to rebuild search index: %w", err)
+		}
+		return nil
+	})
+}
+
+// Search finds the records that match the full-text query, best matches first. Every word of the
+// query has to occur in one of the searchable columns, where the last word also matches as a
+// prefix, so results can be shown while typing. Domain scoping and soft deletes apply as for
+// other queries.
+func (r *Repository[S, T]) Search(ctx context.Context, query string, opts ...SearchOptions) ([]SearchResult[T], error) {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "repository.search", trace.WithAttributes(attribute.String("table", r.zero.TableName())))
+	defer span.End()
+
+	fields, err := r.searchFields()
+	if err != nil {
+		return nil, err
+	}
+
+	match := searchQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	var opt SearchOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.SnippetTokens <= 0 {
+		opt.SnippetTokens = 16
+	}
+
+	table := r.zero.TableName()
+	index := table + "_search"
+
+	columns := make([]string, 0, len(r.zero.Fields())+1)
+	columns = append(columns, "t.id")
+	for _, f := range r.zero.Fields() {
+		columns = append(columns, "t."+f)
+	}
+	columns = append(columns,
+		fmt.Sprintf("bm25(%s)", index),
+		fmt.Sprintf("snippet(%s, -1, '%s', '%s', '…', %d)", index, searchMatchStart, searchMatchEnd, opt.SnippetTokens),
+	)
+	for i := range fields {
+		columns = append(columns, fmt.Sprintf("highlight(%s, %d, '%s', '%s')", index, i, searchMatchStart, searchMatchEnd))
+	}
+
+	// The scope conditions only refer to the model table, keep them in a subquery so they can't
+	// be confused with the columns of the index
+	scope, args := r.Select().(*QueryBuilder[S, T]).where(ctx)
+	stmt := fmt.Sprintf("SELECT %s FROM %s JOIN %s AS t ON t.rowid = %s.rowid WHERE %s MATCH ?",
+		strings.Join(columns, ", "), index, table, index, index)
+	args = append([]any{match}, args...)
+	if scope != "" {
+		stmt += fmt.Sprintf(" AND %s.rowid IN (SELECT rowid FROM %s%s)", index, table, scope)
+	}
+	stmt += fmt.Sprintf(" ORDER BY bm25(%s)", index)
+	if opt.Limit > 0 {
+		stmt += fmt.Sprintf(" LIMIT %d", opt.Limit)
+	}
+	if opt.Offset > 0 {
+		if opt.Limit <= 0 {
+			stmt += " LIMIT -1"
+		}
+		stmt += fmt.Sprintf(" OFFSET %d", opt.Offset)
+	}
+
+	rows, err := FromContext(ctx).QueryContext(ctx, stmt, args...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SearchResult[T]
+	for rows.Next() {
+		var rank float64
+		var snippet string
+		highlights := make([]string, len(fields))
+
+		extra := []any{&rank, &snippet}
+		for i := range highlights {
+			extra = append(extra, &highlights[i])
+		}
+
+		record, err := r.zero.Scan(ctx, r.schema, searchRow{rows: rows, extra: extra})
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+
+		result := SearchResult[T]{
+			Record:     record,
+			Rank:       rank,
+			Snippet:    markMatches(snippet),
+			Highlights: make(map[string]template.HTML, len(fields)),
+		}
+		for i, f := range fields {
+			result.Highlights[f] = markMatches(highlights[i])
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// searchRow scans the columns of the model into the destinations of the model's Scan method, and
+// the search columns that follow them into extra
+type searchRow struct {
+	rows  Scanner
+	extra []any
+}
+
+func (s searchRow) Scan(dest ...any) error {
+	return s.rows.Scan(append(dest, s.extra...)...)
+}
+
+// searchQuery turns user input into an FTS5 query. Every word is quoted, so characters with a
+// meaning in the FTS5 query syntax are searched literally, and the last word matches as a prefix.
+func searchQuery(input string) string {
+	words := strings.Fields(input)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+	}
+	if len(words) > 0 {
+		words[len(words)-1] += "*"
+	}
+	return strings.Join(words, " ")
+}
+
+// markMatches escapes the text for HTML and wraps the matched terms in <mark> tags
+func markMatches(text string) template.HTML {
+	escaped := html.EscapeString(text)
+	escaped = strings.ReplaceAll(escaped, searchMatchStart, "<mark>")
+	escaped = strings.ReplaceAll(escaped, searchMatchEnd, "</mark>")
+	return template.HTML(escaped)
+}
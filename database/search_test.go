@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Article struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	DomainScopedModel
+	SoftDeleteModel
+}
+
+func (a *Article) TableName() string { return "articles" }
+func (a *Article) Fields() []string {
+	return []string{"title", "body", "domain", "deleted_at"}
+}
+func (a *Article) Values() []any {
+	return []any{a.Title, a.Body, a.Domain, a.DeletedAt}
+}
+func (a *Article) Scan(ctx context.Context, schema any, row Scanner) (*Article, error) {
+	var res Article
+	err := row.Scan(&res.ID, &res.Title, &res.Body, &res.Domain, &res.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+func (a *Article) HasAutoIncrementID() bool { return true }
+func (a *Article) GetID() any               { return a.ID }
+func (a *Article) SearchFields() []string   { return []string{"title", "body"} }
+
+func TestSearch(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	var fts5 int
+	require.NoError(t, db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5))
+	if fts5 == 0 {
+		t.Skip("SQLite was built without FTS5, run the tests with -tags sqlite_fts5")
+	}
+
+	_, err = db.Exec("CREATE TABLE articles (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT, body TEXT, domain TEXT, deleted_at TIMESTAMP)")
+	require.NoError(t, err)
+
+	ctx := WithDB(context.Background(), db)
+	repo := NewRepository[any, *Article](nil)
+
+	// Records created before the index are indexed by CreateSearchIndex
+	_, err = repo.Create(ctx, &Article{Title: "Getting started", Body: "Install <tracks> and create a project", DomainScopedModel: DomainScopedModel{Domain: "a.example.com"}})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.CreateSearchIndex(ctx))
+	require.NoError(t, repo.CreateSearchIndex(ctx), "creating the index again is a no-op")
+
+	_, err = repo.Create(ctx, &Article{Title: "Deploying", Body: "Deploy the project to production, the project runs anywhere", DomainScopedModel: DomainScopedModel{Domain: "a.example.com"}})
+	require.NoError(t, err)
+	other, err := repo.Create(ctx, &Article{Title: "Other tenant", Body: "A project of another tenant", DomainScopedModel: DomainScopedModel{Domain: "b.example.com"}})
+	require.NoError(t, err)
+
+	t.Run("Search ranks results", func(t *testing.T) {
+		results, err := repo.Search(ctx, "project")
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, "Deploying", results[0].Record.Title)
+		for i := 1; i < len(results); i++ {
+			assert.LessOrEqual(t, results[i-1].Rank, results[i].Rank)
+		}
+	})
+
+	t.Run("Search returns escaped snippets and highlights", func(t *testing.T) {
+		results, err := repo.Search(ctx, "install")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "<mark>Install</mark> &lt;tracks&gt; and create a project", string(results[0].Snippet))
+		assert.Equal(t, "Getting started", string(results[0].Highlights["title"]))
+		assert.Equal(t, "<mark>Install</mark> &lt;tracks&gt; and create a project", string(results[0].Highlights["body"]))
+	})
+
+	t.Run("Search matches the last word as a prefix", func(t *testing.T) {
+		results, err := repo.Search(ctx, "deploy produc")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "Deploying", results[0].Record.Title)
+	})
+
+	t.Run("Search treats query syntax literally", func(t *testing.T) {
+		results, err := repo.Search(ctx, `project" OR -tenant*`)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		results, err = repo.Search(ctx, "   ")
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("Search respects domain scoping", func(t *testing.T) {
+		scoped := WithDomainFiltering(WithDomain(ctx, "b.example.com"), true)
+		results, err := repo.Search(scoped, "project")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, other.ID, results[0].Record.ID)
+	})
+
+	t.Run("Search follows updates and deletes", func(t *testing.T) {
+		other.Body = "Nothing to see"
+		require.NoError(t, repo.Update(ctx, other))
+
+		results, err := repo.Search(ctx, "project")
+		require.NoError(t, err)
+		assert.Len(t, results, 2)
+
+		results, err = repo.Search(ctx, "project", SearchOptions{Limit: 1, Offset: 1})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "Getting started", results[0].Record.Title)
+
+		first, err := repo.Search(ctx, "install")
+		require.NoError(t, err)
+		require.Len(t, first, 1)
+		require.NoError(t, repo.Delete(ctx, first[0].Record))
+
+		results, err = repo.Search(ctx, "install")
+		require.NoError(t, err)
+		assert.Empty(t, results, "soft-deleted records are excluded")
+
+		results, err = repo.Search(WithDeleted(ctx), "install")
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("Models must be searchable", func(t *testing.T) {
+		_, err := NewRepository[any, *Note](nil).Search(ctx, "hello")
+		assert.ErrorIs(t, err, ErrNotSearchable)
+	})
+
+	t.Run("SearchIndexSQL can be reverted", func(t *testing.T) {
+		_, down := SearchIndexSQL("articles", []string{"title", "body"})
+		_, err := db.Exec(down)
+		require.NoError(t, err)
+
+		var count int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name LIKE 'articles_search%'").Scan(&count))
+		assert.Zero(t, count)
+	})
+}
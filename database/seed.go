@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v3"
+)
+
+// SeedFunc fills the database in the context with data
+type SeedFunc func(ctx context.Context) error
+
+type seed struct {
+	name string
+	fn   SeedFunc
+}
+
+// fixtureLoader creates a single record from a fixture
+type fixtureLoader struct {
+	table string
+	load  func(ctx context.Context, fixture *yaml.Node) error
+}
+
+var (
+	seedsMu  sync.RWMutex
+	seeds    = make(map[Type][]seed)
+	fixtures = make(map[Type][]fixtureLoader)
+)
+
+// RegisterSeed registers a named seed function for the central or tenant database. Seeds run in
+// the order they were registered.
+func RegisterSeed(dbType Type, name string, fn SeedFunc) {
+	seedsMu.Lock()
+	defer seedsMu.Unlock()
+
+	seeds[dbType] = append(seeds[dbType], seed{name: name, fn: fn})
+}
+
+// Seed runs the seeds registered for the database type on db, or only the seeds with the given
+// names. All seeds run in a single transaction, so a failing seed leaves the database untouched.
+func Seed(ctx context.Context, db Database, dbType Type, names ...string) error {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "database.seed", trace.WithAttributes(attribute.String("type", string(dbType))))
+	defer span.End()
+
+	seedsMu.RLock()
+	registered := slices.Clone(seeds[dbType])
+	seedsMu.RUnlock()
+
+	for _, name := range names {
+		if !slices.ContainsFunc(registered, func(s seed) bool { return s.name == name }) {
+			return fmt.Errorf("unknown %s seed: %s", dbType, name)
+		}
+	}
+
+	return WithTransaction(WithDB(ctx, db), func(ctx context.Context) error {
+		for _, s := range registered {
+			if len(names) > 0 && !slices.Contains(names, s.name) {
+				continue
+			}
+			if err := s.fn(ctx); err != nil {
+				span.RecordError(err)
+				return fmt.Errorf("seed %s failed: %w", s.name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// RegisterFixtures makes the table of the repository loadable from YAML fixture files by
+// LoadFixtures. Tables are loaded in the order they were registered, so register tables before
+// the tables that reference them.
+func RegisterFixtures[S Schema, T Model[S, T]](dbType Type, repo *Repository[S, T]) {
+	seedsMu.Lock()
+	defer seedsMu.Unlock()
+
+	fixtures[dbType] = append(fixtures[dbType], fixtureLoader{
+		table: repo.zero.TableName(),
+		load: func(ctx context.Context, fixture *yaml.Node) error {
+			model, err := decodeFixture[S, T](fixture)
+			if err != nil {
+				return err
+			}
+			_, err = repo.Create(withExplicitID(ctx), model)
+			return err
+		},
+	})
+}
+
+// LoadFixtures creates the records in the fixture files of fsys through the repositories
+// registered with RegisterFixtures, so hooks, timestamps and domain scoping apply as usual.
+//
+// Each file is named after its table, e.g. products.yml, and contains a list of records with
+// the column names as keys. Without an id column, records get their id in the order of the file.
+// An explicit id is inserted as is, also for auto-increment models, so other fixtures can refer
+// to the record by its id.
+func LoadFixtures(ctx context.Context, dbType Type, fsys fs.FS) error {
+	ctx, span := otel.GetTracerProvider().Tracer("tracks").Start(ctx, "database.loadfixtures", trace.WithAttributes(attribute.String("type", string(dbType))))
+	defer span.End()
+
+	files := make(map[string]string)
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return err
+		}
+		for _, m := range matches {
+			files[strings.TrimSuffix(m, path.Ext(m))] = m
+		}
+	}
+
+	seedsMu.RLock()
+	loaders := slices.Clone(fixtures[dbType])
+	seedsMu.RUnlock()
+
+	for table := range files {
+		if !slices.ContainsFunc(loaders, func(l fixtureLoader) bool { return l.table == table }) {
+			return fmt.Errorf("no %s fixtures registered for table %s", dbType, table)
+		}
+	}
+
+	return WithTransaction(ctx, func(ctx context.Context) error {
+		for _, l := range loaders {
+			file, ok := files[l.table]
+			if !ok {
+				continue
+			}
+
+			content, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return err
+			}
+
+			var records []yaml.Node
+			if err := yaml.Unmarshal(content, &records); err != nil {
+				return fmt.Errorf("invalid fixtures in %s: %w", file, err)
+			}
+			for i := range records {
+				if err := l.load(ctx, &records[i]); err != nil {
+					span.RecordError(err)
+					return fmt.Errorf("failed to load fixture %d of %s: %w", i, file, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// decodeFixture creates a new model from a fixture. The columns of the fixture are matched to the
+// struct fields by name, ignoring case and underscores, e.g. db_path sets the DBPath field.
+func decodeFixture[S Schema, T Model[S, T]](fixture *yaml.Node) (T, error) {
+	var model T
+	if fixture.Kind != yaml.MappingNode {
+		return model, errors.New("fixture is not a mapping")
+	}
+
+	rv := reflect.ValueOf(&model).Elem()
+	if rv.Kind() == reflect.Ptr {
+		rv.Set(reflect.New(rv.Type().Elem()))
+		rv = rv.Elem()
+	}
+
+	columns := append([]string{"id"}, model.Fields()...)
+	for i := 0; i < len(fixture.Content); i += 2 {
+		column := fixture.Content[i].Value
+		if !slices.Contains(columns, column) {
+			return model, fmt.Errorf("unknown column %s of %s", column, model.TableName())
+		}
+
+		field, ok := columnField(rv, strings.ReplaceAll(column, "_", ""))
+		if !ok {
+			return model, fmt.Errorf("no field found for column %s of %s", column, model.TableName())
+		}
+		if err := fixture.Content[i+1].Decode(field.Addr().Interface()); err != nil {
+			return model, fmt.Errorf("invalid value for column %s: %w", column, err)
+		}
+	}
+	return model, nil
+}
+
+// columnField finds the struct field for a column name without underscores, including the fields
+// of embedded structs
+func columnField(rv reflect.Value, name string) (reflect.Value, bool) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if strings.EqualFold(f.Name, name) {
+			return rv.Field(i), true
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if field, ok := columnField(rv.Field(i), name); ok {
+				return field, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ProductVariant refers to a product by its id
+type ProductVariant struct {
+	ID        int
+	ProductID int
+	Name      string
+}
+
+func (v *ProductVariant) TableName() string { return "product_variants" }
+func (v *ProductVariant) Fields() []string  { return []string{"product_id", "name"} }
+func (v *ProductVariant) Values() []any     { return []any{v.ProductID, v.Name} }
+func (v *ProductVariant) Scan(ctx context.Context, schema any, row Scanner) (*ProductVariant, error) {
+	var res ProductVariant
+	err := row.Scan(&res.ID, &res.ProductID, &res.Name)
+	return &res, err
+}
+func (v *ProductVariant) HasAutoIncrementID() bool { return true }
+func (v *ProductVariant) GetID() any               { return v.ID }
+
+func TestSeedsAndFixtures(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, price REAL)")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE product_variants (id INTEGER PRIMARY KEY AUTOINCREMENT, product_id INTEGER NOT NULL REFERENCES products (id), name TEXT)")
+	require.NoError(t, err)
+	_, err = db.Exec("PRAGMA foreign_keys = ON")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE notes (id INTEGER PRIMARY KEY AUTOINCREMENT, body TEXT, created_at TIMESTAMP, updated_at TIMESTAMP, deleted_at TIMESTAMP)")
+	require.NoError(t, err)
+
+	ctx := WithDB(context.Background(), db)
+	products := NewRepository[*schema, TestProduct](&schema{})
+	notes := NewRepository[any, *Note](nil)
+	variants := NewRepository[any, *ProductVariant](nil)
+
+	// A dedicated type keeps the registrations of this test apart from the others
+	const seedType Type = "seed_test"
+
+	RegisterSeed(seedType, "products", func(ctx context.Context) error {
+		_, err := products.Create(ctx, TestProduct{Name: "Seeded", Price: 1})
+		return err
+	})
+	RegisterSeed(seedType, "failing", func(ctx context.Context) error {
+		if _, err := products.Create(ctx, TestProduct{Name: "Rolled back"}); err != nil {
+			return err
+		}
+		return errors.New("seed failed")
+	})
+	RegisterFixtures(seedType, notes)
+	RegisterFixtures(seedType, products)
+	RegisterFixtures(seedType, variants)
+
+	t.Run("Seed runs the named seeds", func(t *testing.T) {
+		require.NoError(t, Seed(context.Background(), db, seedType, "products"))
+
+		seeded, err := products.FindBy(ctx, map[string]any{"name": "Seeded"})
+		require.NoError(t, err)
+		assert.Len(t, seeded, 1)
+	})
+
+	t.Run("Seed rolls back on failure", func(t *testing.T) {
+		assert.Error(t, Seed(context.Background(), db, seedType))
+
+		seeded, err := products.FindBy(ctx, map[string]any{"name": "Rolled back"})
+		require.NoError(t, err)
+		assert.Empty(t, seeded)
+	})
+
+	t.Run("Seed rejects unknown seeds", func(t *testing.T) {
+		assert.Error(t, Seed(context.Background(), db, seedType, "unknown"))
+	})
+
+	t.Run("LoadFixtures creates records through the repository", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"products.yml": {Data: []byte("- name: Fixture A\n  price: 2.5\n- name: Fixture B\n  price: 3\n")},
+			"notes.yaml":   {Data: []byte("- body: Imported\n  created_at: 2020-01-01T00:00:00Z\n- body: Fresh\n")},
+		}
+		require.NoError(t, LoadFixtures(ctx, seedType, fsys))
+
+		a, err := products.FindBy(ctx, map[string]any{"name": "Fixture A"})
+		require.NoError(t, err)
+		require.Len(t, a, 1)
+		assert.Equal(t, 2.5, a[0].Price)
+
+		imported, err := notes.FindBy(ctx, map[string]any{"body": "Imported"})
+		require.NoError(t, err)
+		require.Len(t, imported, 1)
+		assert.True(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Equal(imported[0].CreatedAt))
+
+		// Timestamps are filled by the repository
+		fresh, err := notes.FindBy(ctx, map[string]any{"body": "Fresh"})
+		require.NoError(t, err)
+		require.Len(t, fresh, 1)
+		assert.False(t, fresh[0].CreatedAt.IsZero())
+	})
+
+	t.Run("LoadFixtures inserts explicit ids", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"products.yml":         {Data: []byte("- id: 100\n  name: Shirt\n")},
+			"product_variants.yml": {Data: []byte("- product_id: 100\n  name: Large\n")},
+		}
+		require.NoError(t, LoadFixtures(ctx, seedType, fsys))
+
+		shirt, err := products.FindByID(ctx, 100)
+		require.NoError(t, err)
+		assert.Equal(t, "Shirt", shirt.Name)
+
+		large, err := variants.FindBy(ctx, map[string]any{"name": "Large"})
+		require.NoError(t, err)
+		require.Len(t, large, 1)
+		assert.Equal(t, 100, large[0].ProductID)
+	})
+
+	t.Run("LoadFixtures rejects invalid fixtures", func(t *testing.T) {
+		err := LoadFixtures(ctx, seedType, fstest.MapFS{"orders.yml": {Data: []byte("- id: 1\n")}})
+		assert.Error(t, err)
+
+		err = LoadFixtures(ctx, seedType, fstest.MapFS{"products.yml": {Data: []byte("- name: X\n  color: red\n")}})
+		assert.Error(t, err)
+
+		// Nothing of a failed load is kept
+		err = LoadFixtures(ctx, seedType, fstest.MapFS{"products.yml": {Data: []byte("- name: Kept?\n- name: X\n  price: expensive\n")}})
+		assert.Error(t, err)
+		kept, err := products.FindBy(ctx, map[string]any{"name": "Kept?"})
+		require.NoError(t, err)
+		assert.Empty(t, kept)
+	})
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Note struct {
+	ID   int    `json:"id"`
+	Body string `json:"body"`
+	TimestampsModel
+	SoftDeleteModel
+}
+
+func (n *Note) TableName() string { return "notes" }
+func (n *Note) Fields() []string {
+	return []string{"body", "created_at", "updated_at", "deleted_at"}
+}
+func (n *Note) Values() []any {
+	return []any{n.Body, n.CreatedAt, n.UpdatedAt, n.DeletedAt}
+}
+func (n *Note) Scan(ctx context.Context, schema any, row Scanner) (*Note, error) {
+	var res Note
+	err := row.Scan(&res.ID, &res.Body, &res.CreatedAt, &res.UpdatedAt, &res.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+func (n *Note) HasAutoIncrementID() bool { return true }
+func (n *Note) GetID() any               { return n.ID }
+
+func TestTimestampsAndSoftDelete(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE notes (id INTEGER PRIMARY KEY AUTOINCREMENT, body TEXT, created_at TIMESTAMP, updated_at TIMESTAMP, deleted_at TIMESTAMP)")
+	require.NoError(t, err)
+
+	ctx := WithDB(context.Background(), db)
+	repo := NewRepository[any, *Note](nil)
+
+	t.Run("Create sets timestamps", func(t *testing.T) {
+		created, err := repo.Create(ctx, &Note{Body: "hello"})
+		require.NoError(t, err)
+		assert.False(t, created.CreatedAt.IsZero())
+		assert.False(t, created.UpdatedAt.IsZero())
+		assert.Nil(t, created.DeletedAt)
+	})
+
+	t.Run("Create keeps explicit timestamps", func(t *testing.T) {
+		past := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		n := &Note{Body: "imported"}
+		n.CreatedAt = past
+		created, err := repo.Create(ctx, n)
+		require.NoError(t, err)
+		assert.True(t, past.Equal(created.CreatedAt))
+	})
+
+	t.Run("Update bumps updated_at", func(t *testing.T) {
+		n := &Note{Body: "update me"}
+		n.CreatedAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		n.UpdatedAt = n.CreatedAt
+		created, err := repo.Create(ctx, n)
+		require.NoError(t, err)
+
+		created.Body = "updated"
+		require.NoError(t, repo.Update(ctx, created))
+
+		found, err := repo.FindByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.True(t, found.UpdatedAt.After(found.CreatedAt))
+	})
+
+	t.Run("Delete is soft", func(t *testing.T) {
+		created, err := repo.Create(ctx, &Note{Body: "delete me"})
+		require.NoError(t, err)
+
+		require.NoError(t, repo.Delete(ctx, created))
+		assert.NotNil(t, created.DeletedAt)
+
+		found, err := repo.FindByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found)
+
+		found, err = repo.FindByID(WithDeleted(ctx), created.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.NotNil(t, found.DeletedAt)
+
+		var count int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM notes WHERE id = ?", created.ID).Scan(&count))
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Build excludes deleted rows", func(t *testing.T) {
+		query, _ := repo.Select().Where("body = ?", "x").Build(ctx)
+		assert.Equal(t, "SELECT id, body, created_at, updated_at, deleted_at FROM notes WHERE body = ? AND deleted_at IS NULL", query)
+
+		query, _ = repo.Select().Build(WithDeleted(ctx))
+		assert.Equal(t, "SELECT id, body, created_at, updated_at, deleted_at FROM notes", query)
+	})
+}
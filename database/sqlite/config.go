@@ -2,12 +2,62 @@ package sqlite
 
 import (
 	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 type Config struct {
 	Path string `json:"path"`
+	// ReadOnly opens the database in query-only mode, e.g. for a read replica
+	ReadOnly bool `json:"read_only"`
+	// BusyTimeout is the number of milliseconds to wait for a locked database
+	BusyTimeout int `json:"busy_timeout"`
+	// MaxOpenConns limits the number of open connections, zero means unlimited
+	MaxOpenConns int `json:"max_open_conns"`
+	// MaxIdleConns limits the number of idle connections, zero uses the database/sql default
+	MaxIdleConns int `json:"max_idle_conns"`
+	// ConnMaxLifetime is the maximum time a connection is reused, e.g. "30m"
+	ConnMaxLifetime string `json:"conn_max_lifetime"`
 }
 
 func (c Config) Create() (*sql.DB, error) {
-	return New(c.Path)
+	var lifetime time.Duration
+	if c.ConnMaxLifetime != "" {
+		var err error
+		lifetime, err = time.ParseDuration(c.ConnMaxLifetime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid conn_max_lifetime: %w", err)
+		}
+	}
+
+	params := url.Values{}
+	if c.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.Itoa(c.BusyTimeout))
+	}
+	if c.ReadOnly {
+		params.Set("_query_only", "true")
+	}
+
+	dsn := c.Path
+	if len(params) > 0 {
+		dsn += "?" + params.Encode()
+	}
+
+	db, err := open(c.Path, dsn, !c.ReadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(c.MaxOpenConns)
+	}
+	if c.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if lifetime > 0 {
+		db.SetConnMaxLifetime(lifetime)
+	}
+	return db, nil
 }
@@ -15,13 +15,19 @@ import (
 
 // New creates a new SQLite database connection with OpenTelemetry tracing
 func New(dbPath string) (*sql.DB, error) {
+	return open(dbPath, dbPath, true)
+}
+
+// open connects to the database at dbPath using the given data source name, which can hold
+// additional connection parameters. WAL mode is only enabled when the connection is writable.
+func open(dbPath, dsn string, writable bool) (*sql.DB, error) {
 	// Ensure the directory exists
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
 	// Connect to database
-	sqlDB, err := otelsql.Open("sqlite3", dbPath, otelsql.WithAttributes(
+	sqlDB, err := otelsql.Open("sqlite3", dsn, otelsql.WithAttributes(
 		semconv.DBSystemSqlite,
 	))
 	if err != nil {
@@ -34,9 +40,11 @@ func New(dbPath string) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Always enable WAL so we can replicate with litestream if needed
-	if _, err := sqlDB.Exec("PRAGMA journal_mode=WAL;"); err != nil {
-		return nil, fmt.Errorf("failed to enable WAL mode: %w", err)
+	// Always enable WAL on writable connections so we can replicate with litestream if needed
+	if writable {
+		if _, err := sqlDB.Exec("PRAGMA journal_mode=WAL;"); err != nil {
+			return nil, fmt.Errorf("failed to enable WAL mode: %w", err)
+		}
 	}
 
 	// Register DB stats to meter
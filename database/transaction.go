@@ -1,18 +1,31 @@
 package database
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"sync"
 )
 
+// ErrReadOnlyTransaction is returned when a statement is executed in a read-only transaction.
+var ErrReadOnlyTransaction = errors.New("transaction is read-only")
+
 // TxFunc is a function that performs operations within a transaction.
 type TxFunc func(ctx context.Context) error
 
 // txWrapper wraps an *sql.Tx to satisfy the Database interface.
 type txWrapper struct {
 	*sql.Tx
+
+	readOnly bool
+
+	mu sync.Mutex
+	// savepoints counts the savepoints created so far, to give each one a unique name
+	savepoints int
+	// afterCommit holds the callbacks to run once the transaction is committed
+	afterCommit []func(ctx context.Context)
 }
 
 // Close is a no-op for a transaction wrapper, as the transaction lifecycle
@@ -21,12 +34,46 @@ func (t *txWrapper) Close() error {
 	return nil
 }
 
+// QueryContext executes a query that returns rows within the transaction
+func (t *txWrapper) QueryContext(ctx context.Context, query string, args ...any) (rows *sql.Rows, err error) {
+	rows, violation := observe(ctx, query, true, func() *sql.Rows {
+		rows, err = t.Tx.QueryContext(ctx, query, args...)
+		return rows
+	}, closeRows)
+	return rows, cmp.Or(err, violation)
+}
+
+// QueryRowContext executes a query that returns a single row within the transaction
+func (t *txWrapper) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	row, _ := observe(ctx, query, true, func() *sql.Row {
+		return t.Tx.QueryRowContext(ctx, query, args...)
+	}, nil)
+	return row
+}
+
+// ExecContext executes a query that doesn't return rows, unless the transaction is read-only.
+// SQLite ignores the ReadOnly transaction option, so it is enforced here instead.
+func (t *txWrapper) ExecContext(ctx context.Context, query string, args ...any) (res sql.Result, err error) {
+	if t.readOnly {
+		return nil, ErrReadOnlyTransaction
+	}
+	res, violation := observe(ctx, query, false, func() sql.Result {
+		res, err = t.Tx.ExecContext(ctx, query, args...)
+		return res
+	}, nil)
+	return res, cmp.Or(err, violation)
+}
+
 // Ensure txWrapper implements Database
 var _ Database = (*txWrapper)(nil)
 
-// WithTransaction creates a new transaction or reuses an existing one.
+// WithTransaction creates a new transaction, or a savepoint when a transaction is already active.
 // It injects the transaction into the context so that repositories use it automatically.
-func WithTransaction(ctx context.Context, fn TxFunc) error {
+//
+// When fn returns an error or panics, only the work done within this call is rolled back, so a
+// caller of a nested WithTransaction can recover from its failure. The options only apply when a
+// new transaction is started; nested calls inherit them from the outer transaction.
+func WithTransaction(ctx context.Context, fn TxFunc, opts ...sql.TxOptions) error {
 	db := FromContext(ctx)
 	if db == nil {
 		return errors.New("database not found in context")
@@ -34,12 +81,12 @@ func WithTransaction(ctx context.Context, fn TxFunc) error {
 
 	// 1. Check if a transaction already exists in ctx
 	// We check if the DB currently in context is already a *txWrapper
-	if _, ok := db.(*txWrapper); ok {
-		return fn(ctx)
+	if tx, ok := db.(*txWrapper); ok {
+		return tx.savepoint(ctx, fn)
 	}
 
-	// 2. Get *sql.DB from ctx
-	sqlDB, ok := db.(*sql.DB)
+	// 2. Get *sql.DB from ctx, transactions always run on the primary
+	primary, ok := sqlDB(db)
 	if !ok {
 		// If the database in context is not *sql.DB and not a *txWrapper,
 		// we can't start a transaction on it.
@@ -47,13 +94,21 @@ func WithTransaction(ctx context.Context, fn TxFunc) error {
 	}
 
 	// 3. Start Transaction
-	tx, err := sqlDB.BeginTx(ctx, nil)
+	var txOpts *sql.TxOptions
+	if len(opts) > 0 {
+		txOpts = &opts[0]
+	}
+	tx, err := primary.BeginTx(ctx, txOpts)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	if txOpts == nil || !txOpts.ReadOnly {
+		markWritten(ctx)
+	}
 
 	// 4. Wrap Tx and inject into new Context
-	txCtx := WithDB(ctx, &txWrapper{Tx: tx})
+	wrapper := &txWrapper{Tx: tx, readOnly: txOpts != nil && txOpts.ReadOnly}
+	txCtx := WithDB(ctx, wrapper)
 
 	// 5. Execute Callback
 	// Panic handling: Ensure rollback on panic
@@ -74,5 +129,67 @@ func WithTransaction(ctx context.Context, fn TxFunc) error {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	// 7. Run the after commit callbacks outside the transaction
+	for _, cb := range wrapper.afterCommit {
+		cb(ctx)
+	}
+
 	return nil
 }
+
+// savepoint runs fn within a savepoint of the transaction
+func (t *txWrapper) savepoint(ctx context.Context, fn TxFunc) error {
+	t.mu.Lock()
+	t.savepoints++
+	name := fmt.Sprintf("tracks_sp_%d", t.savepoints)
+	callbacks := len(t.afterCommit)
+	t.mu.Unlock()
+
+	if _, err := t.Tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	rollback := func() {
+		_, _ = t.Tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+		_, _ = t.Tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+
+		// Callbacks registered within the savepoint no longer apply
+		t.mu.Lock()
+		t.afterCommit = t.afterCommit[:callbacks]
+		t.mu.Unlock()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			rollback()
+			panic(r) // Re-panic after rollback
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		rollback()
+		return err
+	}
+
+	if _, err := t.Tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
+
+// AfterCommit registers fn to run once the transaction in ctx has been committed, which makes it
+// safe to enqueue jobs or send mail that depend on the changes made in the transaction. The
+// callback is dropped when the transaction, or the savepoint it was registered in, is rolled back.
+// Without an active transaction, fn runs immediately.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	tx, ok := FromContext(ctx).(*txWrapper)
+	if !ok {
+		fn(ctx)
+		return
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.afterCommit = append(tx.afterCommit, fn)
+}
@@ -103,17 +103,8 @@ func TestWithTransaction(t *testing.T) {
 	})
 
     t.Run("Nested Transaction Rollback", func(t *testing.T) {
-        // If the inner transaction fails, the whole thing should fail because we reuse the transaction.
-        // NOTE: In true nested transactions (savepoints), inner could fail and outer could recover.
-        // But here we implement REUSE. So if inner returns error, it doesn't rollback immediately?
-        // Wait, looking at implementation:
-        /*
-        	if _, ok := db.(*txWrapper); ok {
-		        return fn(ctx)
-	        }
-        */
-        // It just calls fn(ctx). If fn returns error, the outer WithTransaction sees the error.
-        
+        // The inner transaction is rolled back to its savepoint. The outer transaction
+        // propagates the error, which rolls back everything.
 		err := WithTransaction(ctx, func(txCtx context.Context) error {
 			txDB := FromContext(txCtx)
 			_, err := txDB.ExecContext(txCtx, "INSERT INTO test (value) VALUES (?)", "nested_rollback_outer")
@@ -125,7 +116,6 @@ func TestWithTransaction(t *testing.T) {
                 require.NoError(t, err)
 				return errors.New("inner error")
 			})
-            // If inner returns error, we (outer) return error, triggering rollback of the single shared transaction.
 			return err
 		})
 		assert.Error(t, err)
@@ -136,4 +126,91 @@ func TestWithTransaction(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, 0, count)
 	})
+
+	t.Run("Nested Transaction Recovers", func(t *testing.T) {
+		err := WithTransaction(ctx, func(txCtx context.Context) error {
+			txDB := FromContext(txCtx)
+			_, err := txDB.ExecContext(txCtx, "INSERT INTO test (value) VALUES (?)", "savepoint_outer")
+			require.NoError(t, err)
+
+			err = WithTransaction(txCtx, func(innerCtx context.Context) error {
+				_, err := FromContext(innerCtx).ExecContext(innerCtx, "INSERT INTO test (value) VALUES (?)", "savepoint_inner")
+				require.NoError(t, err)
+				return errors.New("inner error")
+			})
+			assert.Error(t, err)
+
+			// The outer transaction ignores the failure and commits
+			return nil
+		})
+		require.NoError(t, err)
+
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM test WHERE value = ?", "savepoint_outer").Scan(&count)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		err = db.QueryRow("SELECT COUNT(*) FROM test WHERE value = ?", "savepoint_inner").Scan(&count)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("After Commit", func(t *testing.T) {
+		var calls []string
+		err := WithTransaction(ctx, func(txCtx context.Context) error {
+			AfterCommit(txCtx, func(ctx context.Context) {
+				// The callback runs outside the transaction
+				_, isTx := FromContext(ctx).(*txWrapper)
+				assert.False(t, isTx)
+				calls = append(calls, "outer")
+			})
+
+			_ = WithTransaction(txCtx, func(innerCtx context.Context) error {
+				AfterCommit(innerCtx, func(ctx context.Context) {
+					calls = append(calls, "rolled back")
+				})
+				return errors.New("inner error")
+			})
+
+			_ = WithTransaction(txCtx, func(innerCtx context.Context) error {
+				AfterCommit(innerCtx, func(ctx context.Context) {
+					calls = append(calls, "inner")
+				})
+				return nil
+			})
+
+			assert.Empty(t, calls)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"outer", "inner"}, calls)
+
+		calls = nil
+		err = WithTransaction(ctx, func(txCtx context.Context) error {
+			AfterCommit(txCtx, func(ctx context.Context) {
+				calls = append(calls, "never")
+			})
+			return errors.New("intentional error")
+		})
+		assert.Error(t, err)
+		assert.Empty(t, calls)
+
+		// Without a transaction the callback runs immediately
+		AfterCommit(ctx, func(ctx context.Context) {
+			calls = append(calls, "immediate")
+		})
+		assert.Equal(t, []string{"immediate"}, calls)
+	})
+
+	t.Run("Read Only", func(t *testing.T) {
+		err := WithTransaction(ctx, func(txCtx context.Context) error {
+			var count int
+			err := FromContext(txCtx).QueryRowContext(txCtx, "SELECT COUNT(*) FROM test").Scan(&count)
+			require.NoError(t, err)
+
+			_, err = FromContext(txCtx).ExecContext(txCtx, "INSERT INTO test (value) VALUES (?)", "read_only")
+			return err
+		}, sql.TxOptions{ReadOnly: true})
+		assert.ErrorIs(t, err, ErrReadOnlyTransaction)
+	})
 }
@@ -0,0 +1,68 @@
+package tracks
+
+import (
+	"slices"
+	"strings"
+)
+
+// GroupConfig configures the routes that are registered on a group
+type GroupConfig struct {
+	// Layout is the layout of the routes in the group, instead of the application layout
+	Layout string
+	// Views is the directory the views and layouts of the group are loaded from
+	Views string
+	// Middlewares are applied to every route in the group, before the middlewares of the route itself
+	Middlewares []MiddlewareBuilder
+}
+
+// Group returns a router for the routes under prefix. The routes registered on the group, including
+// static files, redirects and health checks, get the prefix, layout, views and middlewares of the
+// group. Everything else, like the database and the cache, is shared with the router:
+//
+//	admin := r.Group("/admin", tracks.GroupConfig{Layout: "admin", Middlewares: []tracks.MiddlewareBuilder{requireAdmin}})
+//	admin.Resource(&UsersResource{})            // /admin/users/...
+//	admin.Group("/reports").GetFunc("/", ...)   // /admin/reports/
+//
+// Groups can be nested, a nested group adds its prefix and middlewares to those of its parent.
+// Request middlewares added to a group only apply to the routes of the group.
+func (r *router) Group(prefix string, config ...GroupConfig) Router {
+	var conf GroupConfig
+	if len(config) > 0 {
+		conf = config[0]
+	}
+	return r.group(prefix, conf)
+}
+
+func (r *router) group(prefix string, conf GroupConfig) *router {
+	g := *r
+	g.isGroup = true
+	g.base = r
+	if r.base != nil {
+		g.base = r.base
+	}
+	g.prefix = r.path(prefix)
+	if g.prefix == "/" {
+		g.prefix = ""
+	}
+	g.prefix = strings.TrimSuffix(g.prefix, "/")
+	g.middlewares = append(slices.Clone(r.middlewares), conf.Middlewares...)
+	if conf.Layout != "" {
+		g.layout = conf.Layout
+	}
+	if r.templates != nil {
+		basedir := r.templates.basedir
+		if conf.Views != "" {
+			basedir = conf.Views
+		}
+		g.templates = r.templates.scope(basedir)
+	}
+	return &g
+}
+
+// path adds the prefix of the group to a path
+func (r *router) path(p string) string {
+	if p != "" && !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return r.prefix + p
+}
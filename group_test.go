@@ -0,0 +1,186 @@
+package tracks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tag returns a middleware that appends name to the X-Trace header
+func tag(name string) MiddlewareBuilder {
+	return func(r Router) Middleware {
+		return func(next http.Handler) (http.Handler, error) {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Add("X-Trace", name)
+				next.ServeHTTP(w, req)
+			}), nil
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	noop := func(r *http.Request) (any, error) { return "ok", nil }
+
+	r := New(t.Context())
+	r.GetFunc("/", "pages", "home", noop)
+
+	admin := r.Group("/admin", GroupConfig{Layout: "admin", Middlewares: []MiddlewareBuilder{tag("admin")}})
+	admin.GetFunc("/users", "users", "index", noop, tag("route"))
+	admin.RequestMiddleware(func(next http.Handler) (http.Handler, error) {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Add("X-Trace", "request")
+			next.ServeHTTP(w, req)
+		}), nil
+	})
+	admin.Group("reports", GroupConfig{Middlewares: []MiddlewareBuilder{tag("reports")}}).
+		GetFunc("/", "reports", "index", noop)
+
+	h, err := r.Handler()
+	require.NoError(t, err)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Prefixes the routes", func(t *testing.T) {
+		rr := serve("/admin/users")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, []string{"admin", "route"}, rr.Header().Values("X-Trace"))
+	})
+
+	t.Run("Nests groups", func(t *testing.T) {
+		rr := serve("/admin/reports/")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, []string{"admin", "request", "reports"}, rr.Header().Values("X-Trace"))
+	})
+
+	t.Run("Keeps the middlewares out of other routes", func(t *testing.T) {
+		rr := serve("/")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Values("X-Trace"))
+	})
+
+	t.Run("Registers the layout of the group", func(t *testing.T) {
+		layouts := make(map[string]string)
+		for _, route := range r.Routes() {
+			layouts[route.Path] = route.Layout
+		}
+		assert.Equal(t, map[string]string{"/": "application", "/admin/users": "admin", "/admin/reports/": "admin"}, layouts)
+	})
+
+	t.Run("Generates the URLs of group routes", func(t *testing.T) {
+		u, err := r.URLFor("reports#index")
+		require.NoError(t, err)
+		assert.Equal(t, "/admin/reports/", u)
+	})
+}
+
+func TestGroupShared(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/app.css", []byte("body {}"), 0644))
+
+	r := New(t.Context())
+	admin := r.Group("/admin", GroupConfig{Middlewares: []MiddlewareBuilder{tag("admin")}})
+	admin.Static("/assets", dir)
+	admin.Redirect("/old", "/admin/new")
+	admin.HealthCheck("/health", HealthConfig{Checks: []HealthCheck{{Name: "ok", Check: func(context.Context) error { return nil }}}})
+
+	h, err := r.Handler()
+	require.NoError(t, err)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Serves static files under the prefix", func(t *testing.T) {
+		rr := serve("/admin/assets/app.css")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "body {}", rr.Body.String())
+		assert.Equal(t, []string{"admin"}, rr.Header().Values("X-Trace"))
+	})
+
+	t.Run("Redirects under the prefix", func(t *testing.T) {
+		rr := serve("/admin/old")
+		assert.Equal(t, http.StatusMovedPermanently, rr.Code)
+		assert.Equal(t, "/admin/new", rr.Header().Get("Location"))
+		assert.Equal(t, []string{"admin"}, rr.Header().Values("X-Trace"))
+	})
+
+	t.Run("Checks the health under the prefix", func(t *testing.T) {
+		rr := serve("/admin/health")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, []string{"admin"}, rr.Header().Values("X-Trace"))
+	})
+
+	t.Run("Shares the cache", func(t *testing.T) {
+		c := NewMemoryCache()
+		admin.WithCache(c)
+		assert.Same(t, c, r.Cache())
+		assert.Same(t, c, admin.Group("/reports").Cache())
+	})
+}
+
+func TestGroupViews(t *testing.T) {
+	require.NoError(t, writeTemplate("views/layouts/application.gohtml", `app: {{ template "yield" .Content }}`))
+	require.NoError(t, writeTemplate("views/admin/layouts/admin.gohtml", `admin: {{ template "yield" .Content }}`))
+	require.NoError(t, writeTemplate("views/admin/users/index.gohtml", `{{ . }}`))
+	defer os.RemoveAll("views")
+
+	r := New(t.Context())
+	admin := r.Group("/admin", GroupConfig{Layout: "admin", Views: "views/admin"})
+	h, err := admin.GetFunc("/users", "users", "index", func(r *http.Request) (any, error) {
+		return "users", nil
+	}).Handler()
+	require.NoError(t, err)
+
+	assert.Equal(t, "./views", r.Templates().basedir)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+	req.Header.Set("Accept", "text/html")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	assert.Equal(t, "admin: users", rr.Body.String())
+}
+
+func TestVersion(t *testing.T) {
+	noop := func(r *http.Request) (any, error) { return "ok", nil }
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	r := New(t.Context())
+	r.Version("v1", VersionConfig{Deprecated: true, SunsetDate: sunset}).GetFunc("/users", "users", "index", noop)
+	r.Group("/api").Version("v2").GetFunc("/users", "users", "index", noop)
+
+	h, err := r.Handler()
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	req.Header.Set("Accept", "application/json")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "v1", rr.Header().Get("API-Version"))
+	assert.Equal(t, "true", rr.Header().Get("Deprecation"))
+	assert.Equal(t, sunset.Format(http.TimeFormat), rr.Header().Get("Sunset"))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/users", nil)
+	req.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "v2", rr.Header().Get("API-Version"))
+	assert.Empty(t, rr.Header().Get("Deprecation"))
+}
@@ -0,0 +1,39 @@
+package tracks
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+const (
+	methodOverrideField  = "_method"
+	methodOverrideHeader = "X-HTTP-Method-Override"
+)
+
+// MethodOverride lets HTML forms, which can only send GET and POST, reach PUT, PATCH and DELETE
+// routes. A POST request is handled as the method in the X-HTTP-Method-Override header or the
+// _method form field. The router applies it to every request, after the global middlewares (and
+// so after CSRF validation) and before the route is matched.
+func MethodOverride(next http.Handler) (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			method := r.Header.Get(methodOverrideHeader)
+			if method == "" {
+				method = r.FormValue(methodOverrideField)
+			}
+
+			switch method = strings.ToUpper(method); method {
+			case http.MethodPut, http.MethodPatch, http.MethodDelete:
+				r.Method = method
+			}
+		}
+		next.ServeHTTP(w, r)
+	}), nil
+}
+
+// MethodField returns a hidden input field that makes a form send its POST request as method
+func MethodField(method string) template.HTML {
+	return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`, methodOverrideField, template.HTMLEscapeString(strings.ToUpper(method))))
+}
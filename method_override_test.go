@@ -0,0 +1,74 @@
+package tracks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodOverride(t *testing.T) {
+	respond := func(s string) ActionFunc {
+		return func(r *http.Request) (any, error) { return s, nil }
+	}
+
+	r := New(t.Context()).
+		CSRFProtection(CSRFConfig{}).
+		PostFunc("/items/{id}", "items", "create", respond("post")).
+		PutFunc("/items/{id}", "items", "update", respond("put")).
+		DeleteFunc("/items/{id}", "items", "destroy", respond("delete"))
+
+	h, err := r.Handler()
+	require.NoError(t, err)
+
+	post := func(form url.Values, header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/items/1", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "token"})
+		if header != "" {
+			req.Header.Set("X-HTTP-Method-Override", header)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Uses the _method field", func(t *testing.T) {
+		rr := post(url.Values{"csrf_token": {"token"}, "_method": {"delete"}}, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `"delete"`, rr.Body.String())
+	})
+
+	t.Run("Uses the override header", func(t *testing.T) {
+		rr := post(url.Values{"csrf_token": {"token"}}, "PUT")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `"put"`, rr.Body.String())
+	})
+
+	t.Run("Ignores other methods", func(t *testing.T) {
+		rr := post(url.Values{"csrf_token": {"token"}, "_method": {"GET"}}, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `"post"`, rr.Body.String())
+	})
+
+	t.Run("Runs after CSRF validation", func(t *testing.T) {
+		rr := post(url.Values{"_method": {"DELETE"}}, "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Only overrides POST requests", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/items/1?_method=DELETE", nil)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	})
+}
+
+func TestMethodField(t *testing.T) {
+	assert.Equal(t, `<input type="hidden" name="_method" value="DELETE">`, string(MethodField("delete")))
+}
@@ -13,6 +13,7 @@ type SystemRole struct {
 	Role      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	database.AuditedModel
 }
 
 // TableName returns the name of the database table for this model
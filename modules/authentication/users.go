@@ -53,6 +53,11 @@ func (*User) Scan(_ context.Context, _ *Schema, row database.Scanner) (*User, er
 	return &n, nil
 }
 
+// AuditExclude keeps the password hash and activation token out of the audit log
+func (*User) AuditExclude() []string {
+	return []string{"password", "activation_token"}
+}
+
 // HasAutoIncrementID returns true if the ID is auto-incremented by the database
 func (*User) HasAutoIncrementID() bool {
 	return false
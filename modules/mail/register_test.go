@@ -33,6 +33,7 @@ func (m *mockRouter) LogHostEntries() tracks.Router
 func (m *mockRouter) LogHostEntriesWithMessage(message string) tracks.Router              { return m }
 func (m *mockRouter) HealthCheck(path string, config ...tracks.HealthConfig) tracks.Router { return m }
 func (m *mockRouter) Version(v string, config ...tracks.VersionConfig) tracks.Router      { return m }
+func (m *mockRouter) Group(prefix string, config ...tracks.GroupConfig) tracks.Router { return m }
 func (m *mockRouter) VersionFromHeader(header, value string, r tracks.Router) tracks.Router  { return m }
 func (m *mockRouter) VersionFromQuery(param, value string, r tracks.Router) tracks.Router   { return m }
 func (m *mockRouter) RateLimit(config tracks.RateLimitConfig) tracks.Router                { return m }
@@ -40,6 +41,8 @@ func (m *mockRouter) WebSocket(path string, handler tracks.WebSocketHandler, mws
 	return m
 }
 func (m *mockRouter) CSRFProtection(config tracks.CSRFConfig) tracks.Router { return m }
+func (m *mockRouter) CORS(config tracks.CORSConfig) tracks.Router                { return m }
+func (m *mockRouter) SecurityHeaders(config tracks.SecurityConfig) tracks.Router { return m }
 func (m *mockRouter) Cache() tracks.Cache                                   { return nil }
 func (m *mockRouter) WithCache(c tracks.Cache) tracks.Router                { return m }
 func (m *mockRouter) Queue() tracks.Queue                                   { return nil }
@@ -48,6 +51,10 @@ func (m *mockRouter) Views(path string) tracks.Router                       { re
 func (m *mockRouter) Page(path, view string) tracks.Router                  { return m }
 func (m *mockRouter) Redirect(origin, destination string) tracks.Router     { return m }
 func (m *mockRouter) Serve(a tracks.Action) tracks.Router                   { return m }
+func (m *mockRouter) As(name string) tracks.Router                          { return m }
+func (m *mockRouter) URLFor(name string, params ...any) (string, error)     { return "", nil }
+func (m *mockRouter) Routes() []tracks.Route                                { return nil }
+func (m *mockRouter) Document(doc tracks.RouteDoc) tracks.Router                 { return m }
 func (m *mockRouter) Controller(c tracks.Controller, mws ...tracks.MiddlewareBuilder) tracks.Router {
 	return m
 }
@@ -73,6 +80,9 @@ func (m *mockRouter) DeleteFunc(path, c, a string, af tracks.ActionFunc, mws ...
 	return m
 }
 func (m *mockRouter) Resource(r tracks.Resource, mws ...tracks.MiddlewareBuilder) tracks.Router { return m }
+func (m *mockRouter) ResourceWithConfig(path string, r tracks.Resource, config tracks.ResourceConfig, mws ...tracks.MiddlewareBuilder) tracks.Router {
+	return m
+}
 func (m *mockRouter) ResourceAtPath(path string, r tracks.Resource, mws ...tracks.MiddlewareBuilder) tracks.Router {
 	return m
 }
@@ -0,0 +1,70 @@
+package multitenancy
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/tmeire/tracks/database"
+)
+
+// TenantBackup is the result of backing up the database of a tenant
+type TenantBackup struct {
+	Tenant *Tenant
+	Path   string
+	Err    error
+}
+
+// BackupTenants backs up the databases of the tenants with the given subdomains, or of all
+// tenants, to <dir>/tenants/<subdomain>, backing up to concurrency databases at the same time. A
+// failing tenant doesn't stop the others, its error is reported in its result.
+func (t *TenantRepository) BackupTenants(ctx context.Context, dir string, concurrency int, opts database.BackupOptions, subdomains ...string) ([]TenantBackup, error) {
+	tenants, err := t.tenants(ctx, subdomains...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]TenantBackup, len(tenants))
+	t.forEachTenant(tenants, concurrency, func(i int, tenant *Tenant) {
+		path, err := database.BackupFile(ctx, tenant.DBPath, filepath.Join(dir, "tenants", tenant.Subdomain), opts)
+		results[i] = TenantBackup{Tenant: tenant, Path: path, Err: err}
+	})
+	return results, nil
+}
+
+// BackupDatabases returns the files of the central database and of all tenant databases, named
+// central and tenants/<subdomain>, to back them up with a tracks.BackupJob
+func (t *TenantRepository) BackupDatabases(ctx context.Context) (map[string]string, error) {
+	central, err := database.FilePath(ctx, t.centralDB)
+	if err != nil {
+		return nil, err
+	}
+
+	tenants, err := t.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{"central": central}
+	for _, tenant := range tenants {
+		files[filepath.Join("tenants", tenant.Subdomain)] = tenant.DBPath
+	}
+	return files, nil
+}
+
+// RestoreTenant replaces the database of the tenant with a backup. The open connection to the
+// tenant database is closed first, but the tenant must not be in use while it's restored.
+func (t *TenantRepository) RestoreTenant(ctx context.Context, subdomain string, backupPath string) error {
+	tenant, err := t.GetTenantBySubdomain(ctx, subdomain)
+	if err != nil {
+		return err
+	}
+
+	t.tenantsMutex.Lock()
+	defer t.tenantsMutex.Unlock()
+
+	if db, ok := t.tenantDBs[tenant.ID]; ok {
+		db.Close()
+		delete(t.tenantDBs, tenant.ID)
+	}
+	return database.Restore(ctx, backupPath, tenant.DBPath)
+}
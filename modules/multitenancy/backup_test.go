@@ -0,0 +1,81 @@
+package multitenancy_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tmeire/tracks/database"
+	"github.com/tmeire/tracks/database/sqlite"
+	"github.com/tmeire/tracks/modules/multitenancy"
+)
+
+func TestBackupTenants(t *testing.T) {
+	ctx := t.Context()
+	tempDir := t.TempDir()
+
+	centralDB, err := sqlite.New(filepath.Join(tempDir, "central.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create central database: %v", err)
+	}
+	defer centralDB.Close()
+
+	err = database.MigrateUpDir(ctx, centralDB, database.CentralDatabase, "./testdata/migrations/central")
+	if err != nil {
+		t.Fatalf("Failed to apply migrations to central database: %v", err)
+	}
+
+	repo := multitenancy.NewTenantRepositoryWithMigrations(centralDB, tempDir, "./testdata/migrations/")
+	tenant, err := repo.CreateTenant(ctx, "Backup", "backup", true)
+	if err != nil {
+		t.Fatalf("Failed to create tenant: %v", err)
+	}
+
+	db, err := repo.GetTenantDB(ctx, tenant.ID)
+	if err != nil {
+		t.Fatalf("Failed to get tenant database: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO stems (name) VALUES ('kept')"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	backupDir := filepath.Join(tempDir, "backups")
+	results, err := repo.BackupTenants(ctx, backupDir, 2, database.BackupOptions{Compress: true})
+	if err != nil {
+		t.Fatalf("Failed to back up tenants: %v", err)
+	}
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("Expected one successful backup, got %+v", results)
+	}
+	if dir := filepath.Dir(results[0].Path); dir != filepath.Join(backupDir, "tenants", "backup") {
+		t.Fatalf("Expected the backup in the directory of the tenant, got %s", dir)
+	}
+
+	files, err := repo.BackupDatabases(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list the databases: %v", err)
+	}
+	if files["central"] != filepath.Join(tempDir, "central.sqlite") || files[filepath.Join("tenants", "backup")] != tenant.DBPath {
+		t.Fatalf("Unexpected databases: %v", files)
+	}
+
+	if _, err := db.ExecContext(ctx, "INSERT INTO stems (name) VALUES ('lost')"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	if err := repo.RestoreTenant(ctx, "backup", results[0].Path); err != nil {
+		t.Fatalf("Failed to restore tenant: %v", err)
+	}
+
+	// The restored database is opened again
+	db, err = repo.GetTenantDB(ctx, tenant.ID)
+	if err != nil {
+		t.Fatalf("Failed to get tenant database: %v", err)
+	}
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM stems").Scan(&count); err != nil {
+		t.Fatalf("Failed to count: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 row after the restore, got %d", count)
+	}
+}
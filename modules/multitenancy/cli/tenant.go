@@ -2,9 +2,15 @@ package cli
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/tmeire/tracks/cli/cmd/db"
 	"github.com/tmeire/tracks/modules/multitenancy/cli/tenant"
 )
 
+func init() {
+	// The tenant databases are backed up with the central database
+	db.RegisterBackup(tenant.Backup)
+}
+
 // TenantCmd returns a cobra.Command for tenant management
 func TenantCmd() *cobra.Command {
 	tenantCmd := &cobra.Command{
@@ -15,6 +21,9 @@ func TenantCmd() *cobra.Command {
 
 	// Add subcommands
 	tenantCmd.AddCommand(tenant.CreateCmd())
+	tenantCmd.AddCommand(tenant.MigrateCmd())
+	tenantCmd.AddCommand(tenant.MigrateStatusCmd())
+	tenantCmd.AddCommand(tenant.RestoreCmd())
 
 	return tenantCmd
 }
@@ -0,0 +1,90 @@
+package tenant
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tmeire/tracks/database"
+	"github.com/tmeire/tracks/database/sqlite"
+	"github.com/tmeire/tracks/modules/multitenancy"
+)
+
+// Backup backs up the databases of all tenants to <dir>/tenants/<subdomain>. It's registered on
+// tracks db backup, and does nothing for applications without tenants. The central database is
+// only read.
+func Backup(cmd *cobra.Command, dbPath, dir string, opts database.BackupOptions) error {
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("failed to open the central database: %w", err)
+	}
+	centralDB, err := sqlite.Config{Path: dbPath, ReadOnly: true}.Create()
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer centralDB.Close()
+
+	// Applications without multitenancy have no tenants to back up
+	var exists int
+	err = centralDB.QueryRowContext(cmd.Context(), "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'tenants'").Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to read the central database: %w", err)
+	}
+	if exists == 0 {
+		return nil
+	}
+
+	repo := multitenancy.NewTenantRepository(centralDB, filepath.Dir(dbPath))
+	results, err := repo.BackupTenants(cmd.Context(), dir, 4, opts)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("  %s: failed: %v\n", r.Tenant.Subdomain, r.Err)
+			continue
+		}
+		fmt.Printf("  %s: %s\n", r.Tenant.Subdomain, r.Path)
+	}
+	fmt.Printf("Backed up %d of %d tenant databases\n", len(results)-failed, len(results))
+
+	if failed > 0 {
+		return fmt.Errorf("failed to back up %d tenant databases", failed)
+	}
+	return nil
+}
+
+// RestoreCmd returns a cobra.Command for restoring the database of a tenant
+func RestoreCmd() *cobra.Command {
+	var dbPath string
+	restoreCmd := &cobra.Command{
+		Use:   "restore [subdomain] [backup]",
+		Short: "Restore the database of a tenant from a backup",
+		Long: `Restore the database of the tenant with the given subdomain from a backup made by db backup.
+
+The backup is checked for integrity before it replaces the database. Stop the application before
+restoring a database.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			centralDB, err := sqlite.New(dbPath)
+			if err != nil {
+				fmt.Printf("Error: failed to connect to database: %v\n", err)
+				os.Exit(1)
+			}
+			defer centralDB.Close()
+
+			repo := multitenancy.NewTenantRepository(centralDB, filepath.Dir(dbPath))
+			if err := repo.RestoreTenant(cmd.Context(), args[0], args[1]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				centralDB.Close()
+				os.Exit(1)
+			}
+			fmt.Printf("Restored the database of tenant %s from %s\n", args[0], args[1])
+		},
+	}
+	restoreCmd.Flags().StringVar(&dbPath, "db", filepath.Join(".", "data", "tracks.sqlite"), "Central database path")
+	return restoreCmd
+}
@@ -0,0 +1,120 @@
+package tenant
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tmeire/tracks/database/sqlite"
+	"github.com/tmeire/tracks/modules/multitenancy"
+)
+
+// tenantRepository opens the central database and returns a TenantRepository for it
+func tenantRepository(dbPath, migrationsDir string) (*multitenancy.TenantRepository, error) {
+	centralDB, err := sqlite.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return multitenancy.NewTenantRepositoryWithMigrations(centralDB, filepath.Join(".", "data"), migrationsDir), nil
+}
+
+// MigrateCmd returns a cobra.Command for migrating the tenant databases
+func MigrateCmd() *cobra.Command {
+	var dbPath string
+	var migrationsDir string
+	var concurrency int
+
+	migrateCmd := &cobra.Command{
+		Use:   "migrate [subdomain...]",
+		Short: "Migrate the tenant databases",
+		Long: `Apply the pending tenant migrations to the databases of all tenants, or of the tenants with
+the given subdomains. Databases are migrated in parallel, and a failing tenant doesn't stop the
+others. The command exits with an error when any tenant failed.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			repo, err := tenantRepository(dbPath, migrationsDir)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer repo.Close()
+
+			results, err := repo.MigrateTenants(cmd.Context(), concurrency, args...)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			var migrated, failed int
+			for _, r := range results {
+				switch {
+				case r.Err != nil:
+					failed++
+					fmt.Printf("  %s: failed after %d migrations: %v\n", r.Tenant.Subdomain, len(r.Applied), r.Err)
+				case len(r.Applied) > 0:
+					migrated++
+					fmt.Printf("  %s: applied %d migrations, now at version %d\n", r.Tenant.Subdomain, len(r.Applied), r.Applied[len(r.Applied)-1])
+				default:
+					fmt.Printf("  %s: up to date\n", r.Tenant.Subdomain)
+				}
+			}
+			fmt.Printf("%d tenants: %d migrated, %d up to date, %d failed\n", len(results), migrated, len(results)-migrated-failed, failed)
+
+			if failed > 0 {
+				repo.Close()
+				os.Exit(1)
+			}
+		},
+	}
+
+	migrateCmd.Flags().StringVar(&dbPath, "db", filepath.Join(".", "data", "tracks.sqlite"), "Central database path")
+	migrateCmd.Flags().StringVar(&migrationsDir, "migrations", filepath.Join(".", "migrations"), "Migrations directory, containing the tenant migrations")
+	migrateCmd.Flags().IntVar(&concurrency, "concurrency", 4, "Number of tenant databases to migrate at the same time")
+
+	return migrateCmd
+}
+
+// MigrateStatusCmd returns a cobra.Command for showing the migration status of the tenant databases
+func MigrateStatusCmd() *cobra.Command {
+	var dbPath string
+	var migrationsDir string
+
+	statusCmd := &cobra.Command{
+		Use:   "migrate:status [subdomain...]",
+		Short: "Show the migration status of the tenant databases",
+		Long:  `Show the current migration version and the pending migrations of all tenant databases, or of the tenants with the given subdomains.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			repo, err := tenantRepository(dbPath, migrationsDir)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer repo.Close()
+
+			statuses, err := repo.MigrationStatus(cmd.Context(), args...)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			var behind int
+			for _, s := range statuses {
+				switch {
+				case s.Err != nil:
+					fmt.Printf("  %s: %v\n", s.Tenant.Subdomain, s.Err)
+				case len(s.Pending) > 0:
+					behind++
+					fmt.Printf("  %s: version %d, %d pending %v\n", s.Tenant.Subdomain, s.Version, len(s.Pending), s.Pending)
+				default:
+					fmt.Printf("  %s: version %d, up to date\n", s.Tenant.Subdomain, s.Version)
+				}
+			}
+			fmt.Printf("%d tenants, %d behind\n", len(statuses), behind)
+		},
+	}
+
+	statusCmd.Flags().StringVar(&dbPath, "db", filepath.Join(".", "data", "tracks.sqlite"), "Central database path")
+	statusCmd.Flags().StringVar(&migrationsDir, "migrations", filepath.Join(".", "migrations"), "Migrations directory, containing the tenant migrations")
+
+	return statusCmd
+}
@@ -64,6 +64,7 @@ type TenantRepository struct {
 	tenantsMutex  sync.RWMutex
 	storageDir    string
 	migrationsDir string
+	autoMigrate   bool
 	schema        *Schema
 }
 
@@ -79,10 +80,18 @@ func NewTenantRepositoryWithMigrations(centralDB database.Database, baseDir stri
 		tenantDBs:     make(map[int]database.Database),
 		storageDir:    baseDir,
 		migrationsDir: migrationsDir,
+		autoMigrate:   true,
 		schema:        NewSchema(),
 	}
 }
 
+// SetAutoMigrate sets whether GetTenantDB applies the pending tenant migrations the first time it
+// opens the database of a tenant, which it does by default. Disable it when the tenant databases
+// are migrated on deploy with `tracks tenant migrate` instead.
+func (t *TenantRepository) SetAutoMigrate(enabled bool) {
+	t.autoMigrate = enabled
+}
+
 // GetCentralDB returns the central database connection
 func (t *TenantRepository) GetCentralDB() database.Database {
 	return t.centralDB
@@ -126,15 +135,19 @@ func (t *TenantRepository) createTenantDB(ctx context.Context, tenantID int) (da
 		return nil, fmt.Errorf("failed to connect to tenant database: %w", err)
 	}
 
-	err = database.MigrateUpDir(ctx, tenantDB, database.TenantDatabase, filepath.Join(t.migrationsDir, "tenant"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to apply migrations: %w", err)
+	if t.autoMigrate {
+		_, err = database.ApplyMigrations(ctx, tenantDB, database.TenantDatabase, t.tenantMigrations())
+		if err != nil {
+			tenantDB.Close()
+			return nil, fmt.Errorf("failed to apply migrations: %w", err)
+		}
 	}
 
 	// Store the connection for future use
-	t.tenantDBs[tenantID] = tenantDB
+	observed := database.NewObserved(tenantDB)
+	t.tenantDBs[tenantID] = observed
 
-	return tenantDB, nil
+	return observed, nil
 }
 
 // CreateTenant creates a new tenant with its own database
@@ -170,12 +183,22 @@ func (t *TenantRepository) CreateTenant(ctx context.Context, name, subdomain str
 
 	// Store the connection for future use
 	t.tenantsMutex.Lock()
-	t.tenantDBs[tenant.ID] = tenantDB
+	t.tenantDBs[tenant.ID] = database.NewObserved(tenantDB)
 	t.tenantsMutex.Unlock()
 
 	return tenant, nil
 }
 
+// SeedTenant runs the tenant database seeds with the given names, or all of them, on the database
+// of the specified tenant
+func (t *TenantRepository) SeedTenant(ctx context.Context, tenantID int, names ...string) error {
+	tenantDB, err := t.GetTenantDB(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	return database.Seed(ctx, tenantDB, database.TenantDatabase, names...)
+}
+
 // Close closes all database connections
 func (t *TenantRepository) Close() error {
 	t.tenantsMutex.Lock()
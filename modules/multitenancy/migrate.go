@@ -0,0 +1,126 @@
+package multitenancy
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/tmeire/tracks/database"
+	"github.com/tmeire/tracks/database/sqlite"
+)
+
+// TenantMigration is the result of migrating the database of a tenant
+type TenantMigration struct {
+	Tenant  *Tenant
+	Applied []int64
+	Err     error
+}
+
+// TenantMigrationStatus describes which migrations of a tenant database are pending
+type TenantMigrationStatus struct {
+	Tenant  *Tenant
+	Version int64
+	Pending []int64
+	Err     error
+}
+
+// tenantMigrations returns the directory with the tenant database migrations
+func (t *TenantRepository) tenantMigrations() string {
+	return filepath.Join(t.migrationsDir, "tenant")
+}
+
+// tenants returns the tenants with the given subdomains, or all tenants
+func (t *TenantRepository) tenants(ctx context.Context, subdomains ...string) ([]*Tenant, error) {
+	if len(subdomains) == 0 {
+		tenants, err := t.schema.Tenants.FindAll(database.WithDB(ctx, t.centralDB))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find tenants: %w", err)
+		}
+		return tenants, nil
+	}
+
+	tenants := make([]*Tenant, 0, len(subdomains))
+	for _, subdomain := range subdomains {
+		tenant, err := t.GetTenantBySubdomain(ctx, subdomain)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants, nil
+}
+
+// MigrateTenants applies the pending migrations to the databases of the tenants with the given
+// subdomains, or of all tenants, migrating up to concurrency databases at the same time. A failing
+// tenant doesn't stop the others, its error is reported in its result.
+func (t *TenantRepository) MigrateTenants(ctx context.Context, concurrency int, subdomains ...string) ([]TenantMigration, error) {
+	tenants, err := t.tenants(ctx, subdomains...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]TenantMigration, len(tenants))
+	t.forEachTenant(tenants, concurrency, func(i int, tenant *Tenant) {
+		results[i] = TenantMigration{Tenant: tenant}
+		results[i].Err = t.withTenantDB(tenant, func(db database.Database) error {
+			applied, err := database.ApplyMigrations(ctx, db, database.TenantDatabase, t.tenantMigrations())
+			results[i].Applied = applied
+			return err
+		})
+	})
+	return results, nil
+}
+
+// MigrationStatus reports the pending migrations of the databases of the tenants with the given
+// subdomains, or of all tenants.
+func (t *TenantRepository) MigrationStatus(ctx context.Context, subdomains ...string) ([]TenantMigrationStatus, error) {
+	tenants, err := t.tenants(ctx, subdomains...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]TenantMigrationStatus, len(tenants))
+	t.forEachTenant(tenants, 1, func(i int, tenant *Tenant) {
+		results[i] = TenantMigrationStatus{Tenant: tenant}
+		results[i].Err = t.withTenantDB(tenant, func(db database.Database) error {
+			version, pending, err := database.PendingMigrations(ctx, db, t.tenantMigrations())
+			results[i].Version = version
+			results[i].Pending = pending
+			return err
+		})
+	})
+	return results, nil
+}
+
+// forEachTenant calls fn for every tenant, with at most concurrency calls at the same time
+func (t *TenantRepository) forEachTenant(tenants []*Tenant, concurrency int, fn func(i int, tenant *Tenant)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, tenant := range tenants {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i, tenant)
+		}()
+	}
+	wg.Wait()
+}
+
+// withTenantDB calls fn with a connection to the database of the tenant that is closed afterwards,
+// so migrating many tenants doesn't keep all their databases open
+func (t *TenantRepository) withTenantDB(tenant *Tenant, fn func(db database.Database) error) error {
+	db, err := sqlite.New(tenant.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to tenant database: %w", err)
+	}
+	defer db.Close()
+
+	return fn(db)
+}
@@ -0,0 +1,123 @@
+package multitenancy_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmeire/tracks/database"
+	"github.com/tmeire/tracks/database/sqlite"
+	"github.com/tmeire/tracks/modules/multitenancy"
+)
+
+const notesMigration = `-- +goose Up
+CREATE TABLE notes (id INTEGER PRIMARY KEY AUTOINCREMENT, body TEXT);
+
+-- +goose Down
+DROP TABLE notes;
+`
+
+func TestMigrateTenants(t *testing.T) {
+	ctx := t.Context()
+	tempDir := t.TempDir()
+
+	centralDB, err := sqlite.New(filepath.Join(tempDir, "central.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create central database: %v", err)
+	}
+	defer centralDB.Close()
+
+	err = database.MigrateUpDir(ctx, centralDB, database.CentralDatabase, "./testdata/migrations/central")
+	if err != nil {
+		t.Fatalf("Failed to apply migrations to central database: %v", err)
+	}
+
+	// Copy the tenant migrations, so a new migration can be added after the tenants were created
+	migrationsDir := filepath.Join(tempDir, "migrations")
+	if err := os.MkdirAll(filepath.Join(migrationsDir, "tenant"), 0755); err != nil {
+		t.Fatalf("Failed to create migrations dir: %v", err)
+	}
+	stems, err := os.ReadFile("./testdata/migrations/tenant/20250507000003_create_stems_table.sql")
+	if err != nil {
+		t.Fatalf("Failed to read migration: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(migrationsDir, "tenant", "20250507000003_create_stems_table.sql"), stems, 0644); err != nil {
+		t.Fatalf("Failed to write migration: %v", err)
+	}
+
+	repo := multitenancy.NewTenantRepositoryWithMigrations(centralDB, tempDir, migrationsDir)
+	for _, subdomain := range []string{"one", "two"} {
+		if _, err := repo.CreateTenant(ctx, subdomain, subdomain, true); err != nil {
+			t.Fatalf("Failed to create tenant: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(migrationsDir, "tenant", "20250601000000_create_notes_table.sql"), []byte(notesMigration), 0644); err != nil {
+		t.Fatalf("Failed to write migration: %v", err)
+	}
+
+	statuses, err := repo.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get the migration status: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 tenants, got %d", len(statuses))
+	}
+	for _, s := range statuses {
+		if s.Err != nil {
+			t.Fatalf("Failed to get the migration status of %s: %v", s.Tenant.Subdomain, s.Err)
+		}
+		if s.Version != 20250507000003 || len(s.Pending) != 1 || s.Pending[0] != 20250601000000 {
+			t.Fatalf("Expected %s to be behind one migration, got version %d and pending %v", s.Tenant.Subdomain, s.Version, s.Pending)
+		}
+	}
+
+	// Opening a tenant database doesn't migrate it when auto-migration is disabled
+	lazy := multitenancy.NewTenantRepositoryWithMigrations(centralDB, tempDir, migrationsDir)
+	lazy.SetAutoMigrate(false)
+	db, err := lazy.GetTenantDB(ctx, statuses[0].Tenant.ID)
+	if err != nil {
+		t.Fatalf("Failed to get tenant database: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "SELECT * FROM notes"); err == nil {
+		t.Fatalf("Expected the notes table not to exist without auto-migration")
+	}
+
+	results, err := repo.MigrateTenants(ctx, 2, "two")
+	if err != nil {
+		t.Fatalf("Failed to migrate tenants: %v", err)
+	}
+	if len(results) != 1 || results[0].Err != nil || len(results[0].Applied) != 1 {
+		t.Fatalf("Expected tenant two to apply one migration, got %+v", results)
+	}
+
+	results, err = repo.MigrateTenants(ctx, 2)
+	if err != nil {
+		t.Fatalf("Failed to migrate tenants: %v", err)
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Fatalf("Failed to migrate %s: %v", r.Tenant.Subdomain, r.Err)
+		}
+		if want := map[string]int{"one": 1, "two": 0}[r.Tenant.Subdomain]; len(r.Applied) != want {
+			t.Fatalf("Expected %s to apply %d migrations, got %v", r.Tenant.Subdomain, want, r.Applied)
+		}
+	}
+
+	// Auto-migration applies pending migrations when a tenant database is first opened
+	if err := os.WriteFile(filepath.Join(migrationsDir, "tenant", "20250602000000_create_tags_table.sql"), []byte("-- +goose Up\nCREATE TABLE tags (id INTEGER PRIMARY KEY);\n"), 0644); err != nil {
+		t.Fatalf("Failed to write migration: %v", err)
+	}
+	eager := multitenancy.NewTenantRepositoryWithMigrations(centralDB, tempDir, migrationsDir)
+	db, err = eager.GetTenantDB(ctx, statuses[0].Tenant.ID)
+	if err != nil {
+		t.Fatalf("Failed to get tenant database: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "SELECT * FROM tags"); err != nil {
+		t.Fatalf("Expected the tags table to be created by auto-migration: %v", err)
+	}
+
+	if _, err := repo.MigrateTenants(ctx, 2, "unknown"); err == nil {
+		t.Fatalf("Expected an error for an unknown tenant")
+	}
+}
@@ -3,7 +3,6 @@ package multitenancy
 import (
 	"context"
 	"github.com/tmeire/tracks/database"
-	"time"
 )
 
 // Tenant represents a tenant in the system
@@ -15,8 +14,8 @@ type Tenant struct {
 	DBPath       string
 	PlanID       string
 	Active       bool
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	database.TimestampsModel
+	database.AuditedModel
 }
 
 // TableName returns the name of the database table for this model
@@ -44,17 +43,6 @@ func (t *Tenant) Scan(_ context.Context, _ *Schema, row database.Scanner) (*Tena
 	return &ret, nil
 }
 
-func (t *Tenant) BeforeCreate(_ context.Context) error {
-	now := time.Now()
-	if t.CreatedAt.IsZero() {
-		t.CreatedAt = now
-	}
-	if t.UpdatedAt.IsZero() {
-		t.UpdatedAt = now
-	}
-	return nil
-}
-
 // HasAutoIncrementID returns true if the ID is auto-incremented by the database
 func (t *Tenant) HasAutoIncrementID() bool {
 	return true
@@ -67,12 +55,12 @@ func (t *Tenant) GetID() any {
 
 // UserRole represents a user's role within a tenant
 type UserRole struct {
-	ID        int64
-	UserID    string
-	TenantID  int64
-	Role      string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID       int64
+	UserID   string
+	TenantID int64
+	Role     string
+	database.TimestampsModel
+	database.AuditedModel
 }
 
 // TableName returns the name of the database table for this model
@@ -100,17 +88,6 @@ func (ur *UserRole) Scan(_ context.Context, _ *Schema, row database.Scanner) (*U
 	return &res, nil
 }
 
-func (ur *UserRole) BeforeCreate(_ context.Context) error {
-	now := time.Now()
-	if ur.CreatedAt.IsZero() {
-		ur.CreatedAt = now
-	}
-	if ur.UpdatedAt.IsZero() {
-		ur.UpdatedAt = now
-	}
-	return nil
-}
-
 // HasAutoIncrementID returns true if the ID is auto-incremented by the database
 func (ur *UserRole) HasAutoIncrementID() bool {
 	return true
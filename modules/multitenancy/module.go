@@ -7,7 +7,6 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
-	"net/url"
 	"path/filepath"
 	"strings"
 
@@ -23,6 +22,9 @@ var migrations embed.FS
 // Config defines the configuration for the multitenancy module
 type Config struct {
 	RootSubdomains []string
+	// SkipAutoMigrate disables applying the tenant migrations when a tenant database is first
+	// opened, for applications that run `tracks tenant migrate` on deploy
+	SkipAutoMigrate bool
 }
 
 // DefaultConfig returns the default configuration for the multitenancy module
@@ -47,6 +49,7 @@ func WithConfig(cfg Config) tracks.Module {
 
 		r.GlobalMiddleware(func(next http.Handler) (http.Handler, error) {
 			tenantDB := NewTenantRepositoryWithMigrations(r.Database(), filepath.Join(".", "data"), filepath.Join(".", "migrations"))
+			tenantDB.SetAutoMigrate(!cfg.SkipAutoMigrate)
 
 			h, err := rn.Handler()
 			if err != nil {
@@ -110,14 +113,6 @@ func (s *splitter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	if err != nil || tenant == nil {
 		if subdomain == "" {
-			if req.Referer() != "" {
-				r, err := url.Parse(req.Referer())
-				if err == nil && strings.HasSuffix(r.Host, req.Host) {
-					// Make sure we can redirect from the base domain to the subdomain
-					w.Header().Set("Access-Control-Allow-Origin", r.Scheme+"://"+r.Host)
-				}
-			}
-
 			s.root.ServeHTTP(w, req.WithContext(ctx))
 			return
 		}
@@ -0,0 +1,643 @@
+package tracks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIConfig configures the OpenAPI document of the application
+type OpenAPIConfig struct {
+	// Path is the path the document is served at, it isn't served when empty
+	Path string `json:"path"`
+	// Title of the API, the name of the application by default
+	Title string `json:"title"`
+	// Description of the API
+	Description string `json:"description"`
+	// Prefix only documents the routes whose path starts with it, e.g. /api
+	Prefix string `json:"prefix"`
+}
+
+// RouteDoc documents a route in the OpenAPI document
+type RouteDoc struct {
+	Summary     string
+	Description string
+	// Tags group the operations, the controller of the route by default
+	Tags []string
+	// Request is a value of the type of the request body, e.g. CreateUserRequest{}
+	Request any
+	// Response is a value of the type of the data the route responds with
+	Response any
+	// Status is the status code of a successful response, 200 by default
+	Status int
+	// Deprecated marks the route as deprecated. Routes of deprecated versions are always deprecated.
+	Deprecated bool
+}
+
+// Document documents the route that was registered last in the OpenAPI document. When the same
+// action was registered for several methods, like update for PUT and POST, all of them are
+// documented. The request and response types of typed actions are kept unless doc sets them.
+//
+//	r.PostFunc("/users/", "users", "create", users.Create).Document(tracks.RouteDoc{
+//		Summary:  "Create a user",
+//		Request:  CreateUser{},
+//		Response: User{},
+//	})
+func (r *router) Document(doc RouteDoc) Router {
+	if err := r.routes.document(doc); err != nil {
+		return errRouter{err}
+	}
+	return r
+}
+
+// document sets the documentation of the last route, and of the routes of the same action at the
+// same path that were registered just before it
+func (t *routeTable) document(doc RouteDoc) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.routes) == 0 {
+		return fmt.Errorf("no route registered to document")
+	}
+	last := t.routes[len(t.routes)-1]
+	for i := len(t.routes) - 1; i >= 0; i-- {
+		route := t.routes[i]
+		if route.Name != last.Name || route.Path != last.Path {
+			break
+		}
+		route.document(doc)
+	}
+	return nil
+}
+
+// document sets the documentation of a route. The request and response types are kept when doc
+// doesn't have them, like for typed actions.
+func (route *Route) document(doc RouteDoc) {
+	if doc.Request == nil {
+		doc.Request = route.Doc.Request
+	}
+	if doc.Response == nil {
+		doc.Response = route.Doc.Response
+	}
+	route.Doc = doc
+
+	if doc.Request != nil {
+		route.Request = reflect.TypeOf(doc.Request).String()
+	}
+	if doc.Response != nil {
+		route.Response = reflect.TypeOf(doc.Response).String()
+	}
+}
+
+// OpenAPI generates the OpenAPI 3.1 document of the routes of the router. The schemas of request
+// and response bodies are derived from the Go types in the RouteDoc of the routes, including the
+// constraints of their validate tags.
+func OpenAPI(r Router) ([]byte, error) {
+	conf := r.Config()
+
+	doc := openAPIDocument{
+		OpenAPI: "3.1.0",
+		Info: openAPIInfo{
+			Title:       conf.OpenAPI.Title,
+			Description: conf.OpenAPI.Description,
+			Version:     conf.Version,
+		},
+		Paths: make(map[string]map[string]*openAPIOperation),
+	}
+	if doc.Info.Title == "" {
+		doc.Info.Title = conf.Name
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = "0.0.0"
+	}
+	if conf.BaseDomain != "" {
+		scheme := "http"
+		if r.Secure() {
+			scheme = "https"
+		}
+		doc.Servers = []openAPIServer{{URL: scheme + "://" + conf.BaseDomain}}
+	}
+
+	schemas := newSchemaRegistry()
+	operationIDs := make(map[string]bool)
+
+	for _, route := range r.Routes() {
+		if route.Method == "" || !strings.HasPrefix(route.Path, conf.OpenAPI.Prefix) {
+			continue
+		}
+		// Routes that are only reachable from another (sub)domain aren't part of this API
+		if route.Router != "root" {
+			continue
+		}
+
+		path, params := openAPIPath(route.Path)
+
+		op := &openAPIOperation{
+			OperationID: openAPIOperationID(route, operationIDs),
+			Summary:     route.Doc.Summary,
+			Description: route.Doc.Description,
+			Tags:        route.Doc.Tags,
+			Deprecated:  route.Deprecated || route.Doc.Deprecated,
+			Responses:   make(map[string]*openAPIResponse),
+		}
+		if len(op.Tags) == 0 && route.Controller != "" {
+			op.Tags = []string{route.Controller}
+		}
+		for _, p := range params {
+			op.Parameters = append(op.Parameters, openAPIParameter{
+				Name:     p,
+				In:       "path",
+				Required: true,
+				Schema:   &jsonSchema{Type: "string"},
+			})
+		}
+
+		if route.Doc.Request != nil {
+			op.Parameters = requestParameters(schemas, reflect.TypeOf(route.Doc.Request), op.Parameters)
+		}
+		if route.Doc.Request != nil && route.Method != http.MethodGet && route.Method != http.MethodHead {
+			// Forms are bound by their form tags, so they have a schema of their own
+			schema := schemas.schema(reflect.TypeOf(route.Doc.Request))
+			form := schemas.form(reflect.TypeOf(route.Doc.Request))
+			op.RequestBody = &openAPIRequestBody{
+				Required: true,
+				Content: map[string]openAPIMediaType{
+					"application/json":                  {Schema: schema},
+					"application/x-www-form-urlencoded": {Schema: form},
+					"multipart/form-data":               {Schema: form},
+				},
+			}
+		}
+
+		status := route.Doc.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := &openAPIResponse{Description: http.StatusText(status)}
+		if route.Doc.Response != nil {
+			success.Content = map[string]openAPIMediaType{
+				"application/json": {Schema: schemas.schema(reflect.TypeOf(route.Doc.Response))},
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = success
+		op.Responses["default"] = &openAPIResponse{
+			Description: "Error",
+			Content: map[string]openAPIMediaType{
+				"application/json": {Schema: schemas.schema(reflect.TypeOf(ErrorData{}))},
+			},
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*openAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	if len(schemas.schemas) > 0 {
+		doc.Components = &openAPIComponents{Schemas: schemas.schemas}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// openAPIHandler serves the OpenAPI document of the router
+func (r *router) openAPIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, err := OpenAPI(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+	})
+}
+
+// writeOpenAPI writes the OpenAPI document to a file, for `tracks openapi`
+func (r *router) writeOpenAPI(path string) error {
+	b, err := OpenAPI(r)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// parameterTags are the tags of the fields Bind fills from the request instead of its body, they're
+// also the location of their OpenAPI parameter
+var parameterTags = []string{"path", "query", "header", "cookie"}
+
+// isParameter reports whether Bind fills a field from the path, query, headers or cookies
+func isParameter(field reflect.StructField) bool {
+	for _, tag := range parameterTags {
+		if name := field.Tag.Get(tag); name != "" && name != "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// requestParameters adds the fields of a request type with path, query, header and cookie tags to
+// the parameters of an operation
+func requestParameters(schemas *schemaRegistry, t reflect.Type, params []openAPIParameter) []openAPIParameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return params
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			params = requestParameters(schemas, field.Type, params)
+			continue
+		}
+
+		for _, tag := range parameterTags {
+			name := field.Tag.Get(tag)
+			if name == "" || name == "-" {
+				continue
+			}
+
+			param := openAPIParameter{Name: name, In: tag, Schema: schemas.schema(field.Type)}
+			param.Required = validateRules(field, param.Schema) || tag == "path"
+
+			// Path values are already listed, from the path of the route
+			idx := slices.IndexFunc(params, func(p openAPIParameter) bool { return p.In == param.In && p.Name == name })
+			if idx >= 0 {
+				params[idx] = param
+			} else if tag != "path" {
+				params = append(params, param)
+			}
+		}
+	}
+	return params
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]*)\}`)
+
+// openAPIPath turns a ServeMux pattern into an OpenAPI path and returns its parameters
+func openAPIPath(pattern string) (string, []string) {
+	var params []string
+	path := pathParamPattern.ReplaceAllStringFunc(pattern, func(m string) string {
+		name := strings.TrimSuffix(m[1:len(m)-1], "...")
+		if name == "$" {
+			return ""
+		}
+		params = append(params, name)
+		return "{" + name + "}"
+	})
+	return path, params
+}
+
+// openAPIOperationID returns a unique ID for the operation of a route
+func openAPIOperationID(route Route, used map[string]bool) string {
+	id := route.Name
+	if route.Version != "" {
+		id = route.Version + ":" + id
+	}
+	if used[id] {
+		id += "_" + strings.ToLower(route.Method)
+	}
+	used[id] = true
+	return id
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Servers    []openAPIServer                         `json:"servers,omitempty"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components *openAPIComponents                      `json:"components,omitempty"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Parameters  []openAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string      `json:"name"`
+	In       string      `json:"in"`
+	Required bool        `json:"required,omitempty"`
+	Schema   *jsonSchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *jsonSchema `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*jsonSchema `json:"schemas,omitempty"`
+}
+
+// jsonSchema is the subset of JSON Schema that is generated for Go types
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 any                    `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+}
+
+// schemaRegistry generates the schemas of Go types. Named struct types are added to the
+// components of the document and referenced, so recursive types are supported.
+type schemaRegistry struct {
+	schemas map[string]*jsonSchema
+	names   map[reflect.Type]string
+	// forms holds the component names of the form schemas of named struct types
+	forms map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]*jsonSchema),
+		names:   make(map[reflect.Type]string),
+		forms:   make(map[reflect.Type]string),
+	}
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	nonNamePart   = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// name returns a unique component name for a named type
+func (s *schemaRegistry) name(t reflect.Type) string {
+	return s.unique(s.names, t, "")
+}
+
+// unique returns the component name of a named type in names, or adds a new one that isn't used
+// by any other schema
+func (s *schemaRegistry) unique(names map[reflect.Type]string, t reflect.Type, suffix string) string {
+	if name, ok := names[t]; ok {
+		return name
+	}
+	name := strings.Trim(nonNamePart.ReplaceAllString(t.Name(), "_"), "_") + suffix
+	for s.taken(name) {
+		name += "_"
+	}
+	names[t] = name
+	return name
+}
+
+func (s *schemaRegistry) taken(name string) bool {
+	for _, names := range []map[reflect.Type]string{s.names, s.forms} {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *schemaRegistry) schema(t reflect.Type) *jsonSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.NumField() == 1 && t.Field(0).Name == "V" && strings.HasPrefix(t.Name(), "JSON["):
+		// database.JSON is encoded as its value
+		return s.schema(t.Field(0).Type)
+	case t.Kind() != reflect.Struct && t.Implements(marshalerType):
+		return &jsonSchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &jsonSchema{Type: "string", Format: "byte"}
+		}
+		return &jsonSchema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name := s.name(t)
+		if _, ok := s.schemas[name]; !ok {
+			// Register the name before generating the properties, for recursive types
+			s.schemas[name] = &jsonSchema{}
+			*s.schemas[name] = *s.object(t)
+		}
+		return &jsonSchema{Ref: "#/components/schemas/" + name}
+	default:
+		return &jsonSchema{}
+	}
+}
+
+// object generates the schema of the fields of a struct
+func (s *schemaRegistry) object(t reflect.Type) *jsonSchema {
+	obj := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+	s.fields(t, obj)
+	return obj
+}
+
+func (s *schemaRegistry) fields(t reflect.Type, obj *jsonSchema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Tag.Get("form")
+		}
+		if name == "-" || (name == "" && isParameter(field)) {
+			continue
+		}
+
+		// Embedded structs without a name are flattened, like encoding/json does
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.fields(ft, obj)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := s.schema(field.Type)
+		if validateRules(field, prop) && !slices.Contains(obj.Required, name) {
+			obj.Required = append(obj.Required, name)
+		}
+		obj.Properties[name] = prop
+	}
+}
+
+// form generates the schema of a type as Bind fills it from a form, with the names of its form
+// tags. It returns nil for types that can't be bound from a form.
+func (s *schemaRegistry) form(t reflect.Type) *jsonSchema {
+	switch {
+	case t == fileHeaderType:
+		return &jsonSchema{Type: "string", Format: "binary"}
+	case isScalar(t):
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t != timeType && reflect.PointerTo(t).Implements(textUnmarshalerType) {
+			return &jsonSchema{Type: "string"}
+		}
+		return s.schema(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.form(t.Elem())
+	case reflect.Slice:
+		items := s.form(t.Elem())
+		if items == nil {
+			return nil
+		}
+		return &jsonSchema{Type: "array", Items: items}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.formObject(t)
+		}
+		name := s.unique(s.forms, t, "Form")
+		if _, ok := s.schemas[name]; !ok {
+			// Register the name before generating the properties, for recursive types
+			s.schemas[name] = &jsonSchema{}
+			*s.schemas[name] = *s.formObject(t)
+		}
+		return &jsonSchema{Ref: "#/components/schemas/" + name}
+	default:
+		return nil
+	}
+}
+
+// formObject generates the form schema of the fields of a struct
+func (s *schemaRegistry) formObject(t reflect.Type) *jsonSchema {
+	obj := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+	s.formFields(t, obj)
+	return obj
+}
+
+func (s *schemaRegistry) formFields(t reflect.Type, obj *jsonSchema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// Embedded structs are flattened, like bindForm does
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			s.formFields(field.Type, obj)
+			continue
+		}
+
+		name := field.Tag.Get("form")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		prop := s.form(field.Type)
+		if prop == nil {
+			continue
+		}
+		if validateRules(field, prop) && !slices.Contains(obj.Required, name) {
+			obj.Required = append(obj.Required, name)
+		}
+		obj.Properties[name] = prop
+	}
+}
+
+// validateRules adds the constraints of the validate tag of a field to its schema, and returns
+// whether the field is required. Only the constraints that Validate enforces are added.
+func validateRules(field reflect.StructField, prop *jsonSchema) bool {
+	tag := field.Tag.Get("validate")
+	if tag == "" {
+		return false
+	}
+	// References can't have constraints next to them
+	if prop.Ref != "" {
+		return strings.Contains(","+tag+",", ",required,")
+	}
+
+	var required bool
+	for _, rule := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(rule, "=")
+		n, _ := strconv.Atoi(value)
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			if field.Type.Kind() == reflect.String {
+				prop.Format = "email"
+			}
+		case "min", "max":
+			switch field.Type.Kind() {
+			case reflect.String:
+				if name == "min" {
+					prop.MinLength = &n
+				} else {
+					prop.MaxLength = &n
+				}
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				f := float64(n)
+				if name == "min" {
+					prop.Minimum = &f
+				} else {
+					prop.Maximum = &f
+				}
+			}
+		}
+	}
+	return required
+}
@@ -0,0 +1,182 @@
+package tracks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type CreateUserRequest struct {
+	Name     string   `json:"name" validate:"required,min=2,max=50"`
+	Email    string   `form:"email" validate:"required,email"`
+	Age      int      `json:"age" validate:"min=18"`
+	Tags     []string `json:"tags" validate:"max=5"`
+	Score    float64  `json:"score" validate:"max=10"`
+	Password string   `json:"-"`
+}
+
+type UserResponse struct {
+	ID        int           `json:"id"`
+	Name      string        `json:"name"`
+	CreatedAt time.Time     `json:"created_at"`
+	Manager   *UserResponse `json:"manager,omitempty"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	noop := func(r *http.Request) (any, error) { return "ok", nil }
+
+	r := New(t.Context())
+	r.Version("v1", VersionConfig{Deprecated: true}).
+		GetFunc("/users/{id}", "users", "show", noop)
+	r.Version("v2").
+		PostFunc("/users/", "users", "create", noop).
+		Document(RouteDoc{
+			Summary:  "Create a user",
+			Request:  CreateUserRequest{},
+			Response: UserResponse{},
+			Status:   http.StatusCreated,
+		}).
+		Serve(Action{
+			Method:     http.MethodGet,
+			Path:       "/files/{path...}",
+			Controller: "files",
+			Name:       "show",
+			Func:       noop,
+			Doc:        &RouteDoc{Tags: []string{"storage"}, Response: []byte{}},
+		})
+
+	b, err := OpenAPI(r)
+	require.NoError(t, err)
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(b, &doc))
+
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.NotEmpty(t, doc.Info.Version)
+
+	t.Run("Documents versions and their deprecation", func(t *testing.T) {
+		show := doc.Paths["/v1/users/{id}"]["get"]
+		require.NotNil(t, show)
+		assert.Equal(t, true, show["deprecated"])
+		assert.Equal(t, "v1:users#show", show["operationId"])
+		assert.Equal(t, []any{"users"}, show["tags"])
+		assert.Equal(t, []any{map[string]any{
+			"name": "id", "in": "path", "required": true, "schema": map[string]any{"type": "string"},
+		}}, show["parameters"])
+
+		create := doc.Paths["/v2/users/"]["post"]
+		require.NotNil(t, create)
+		assert.Nil(t, create["deprecated"])
+		assert.Equal(t, "Create a user", create["summary"])
+	})
+
+	t.Run("Documents request and response types", func(t *testing.T) {
+		create := doc.Paths["/v2/users/"]["post"]
+		responses := create["responses"].(map[string]any)
+		assert.Contains(t, responses, "201")
+		assert.Equal(t, "#/components/schemas/ErrorData",
+			responses["default"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)["$ref"])
+
+		content := create["requestBody"].(map[string]any)["content"].(map[string]any)
+		assert.Contains(t, content, "application/json")
+		assert.Equal(t, map[string]any{"$ref": "#/components/schemas/CreateUserRequestForm"},
+			content["application/x-www-form-urlencoded"].(map[string]any)["schema"])
+
+		// Forms are bound by their form tags only
+		form := doc.Components.Schemas["CreateUserRequestForm"]
+		require.NotNil(t, form)
+		assert.Equal(t, []any{"email"}, form["required"])
+		assert.Equal(t, map[string]any{"email": map[string]any{"type": "string", "format": "email"}}, form["properties"])
+
+		files := doc.Paths["/v2/files/{path}"]["get"]
+		require.NotNil(t, files)
+		assert.Equal(t, []any{"storage"}, files["tags"])
+	})
+
+	t.Run("Converts validation constraints", func(t *testing.T) {
+		req := doc.Components.Schemas["CreateUserRequest"]
+		require.NotNil(t, req)
+		assert.ElementsMatch(t, []any{"name", "email"}, req["required"])
+
+		props := req["properties"].(map[string]any)
+		assert.NotContains(t, props, "Password")
+		assert.Equal(t, map[string]any{"type": "string", "minLength": 2.0, "maxLength": 50.0}, props["name"])
+		assert.Equal(t, map[string]any{"type": "string", "format": "email"}, props["email"])
+		assert.Equal(t, map[string]any{"type": "integer", "minimum": 18.0}, props["age"])
+
+		// Validate doesn't check the length of slices or the value of floats
+		assert.Equal(t, map[string]any{"type": "array", "items": map[string]any{"type": "string"}}, props["tags"])
+		assert.Equal(t, map[string]any{"type": "number"}, props["score"])
+	})
+
+	t.Run("Supports recursive types", func(t *testing.T) {
+		props := doc.Components.Schemas["UserResponse"]["properties"].(map[string]any)
+		assert.Equal(t, map[string]any{"type": "string", "format": "date-time"}, props["created_at"])
+		assert.Equal(t, map[string]any{"$ref": "#/components/schemas/UserResponse"}, props["manager"])
+	})
+
+	t.Run("Documents the Bind parameters", func(t *testing.T) {
+		r := New(t.Context()).
+			PutFunc("/orders/{id}", "orders", "update", noop).
+			Document(RouteDoc{Request: UpdateOrder{}})
+
+		b, err := OpenAPI(r)
+		require.NoError(t, err)
+
+		var doc struct {
+			Paths map[string]map[string]struct {
+				Parameters []openAPIParameter `json:"parameters"`
+			} `json:"paths"`
+			Components openAPIComponents `json:"components"`
+		}
+		require.NoError(t, json.Unmarshal(b, &doc))
+
+		var params []string
+		for _, p := range doc.Paths["/orders/{id}"]["put"].Parameters {
+			params = append(params, p.In+" "+p.Name+" "+p.Schema.Type.(string))
+		}
+		assert.Equal(t, []string{"path id integer", "query page integer", "query filter array", "header X-Token string", "cookie session string"}, params)
+
+		props := doc.Components.Schemas["UpdateOrder"].Properties
+		assert.Contains(t, props, "note")
+		assert.NotContains(t, props, "ID")
+		assert.NotContains(t, props, "Token")
+	})
+
+	t.Run("Serves the document", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		r.(*router).openAPIHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.JSONEq(t, string(b), rr.Body.String())
+	})
+}
+
+func TestRunWritesOpenAPI(t *testing.T) {
+	noop := func(r *http.Request) (any, error) { return nil, nil }
+	r := New(t.Context()).GetFunc("/users/", "users", "index", noop)
+
+	file := t.TempDir() + "/openapi.json"
+	t.Setenv("TRACKS_OPENAPI", file)
+	require.NoError(t, r.Run(t.Context()))
+
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"/users/"`)
+}
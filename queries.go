@@ -0,0 +1,114 @@
+package tracks
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tmeire/tracks/database"
+)
+
+const (
+	defaultSlowQueryThreshold = 100 * time.Millisecond
+	defaultRepeatThreshold    = 5
+)
+
+// QueryStats collects the queries executed while handling each request and logs a summary once
+// the request completes. In development mode, it also detects N+1 and slow queries and exposes
+// the totals in the X-Query-Count and X-Query-Duration response headers. In strict mode, a request
+// that violated one of the checks fails with an internal server error.
+func QueryStats(conf Config) Middleware {
+	var observer database.ObserverConfig
+	if conf.Development {
+		observer = database.ObserverConfig{
+			SlowThreshold:   defaultSlowQueryThreshold,
+			RepeatThreshold: defaultRepeatThreshold,
+			Strict:          conf.Queries.Strict,
+		}
+		if conf.Queries.SlowThreshold != "" {
+			d, err := time.ParseDuration(conf.Queries.SlowThreshold)
+			if err != nil {
+				slog.Error("Invalid slow query threshold, using the default", "threshold", conf.Queries.SlowThreshold, "error", err)
+			} else {
+				observer.SlowThreshold = d
+			}
+		}
+		if conf.Queries.RepeatThreshold > 0 {
+			observer.RepeatThreshold = conf.Queries.RepeatThreshold
+		}
+	}
+
+	level := slog.LevelDebug
+	if conf.Development {
+		level = slog.LevelInfo
+	}
+
+	return func(next http.Handler) (http.Handler, error) {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, stats := database.WithQueryStats(r.Context(), observer)
+
+			if !conf.Development {
+				next.ServeHTTP(w, r.WithContext(ctx))
+			} else {
+				qw := &queryStatsResponseWriter{ResponseWriter: w, stats: stats}
+				next.ServeHTTP(qw, r.WithContext(ctx))
+				if !qw.written {
+					qw.setHeaders()
+				}
+			}
+
+			slog.Log(ctx, level, "Queries executed",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"count", stats.Count(),
+				"duration", stats.Duration(),
+			)
+		}), nil
+	}
+}
+
+// queryStatsResponseWriter adds the query statistics to the response headers before they are sent.
+// When a query violated one of the checks in strict mode, the response is replaced by an error.
+type queryStatsResponseWriter struct {
+	http.ResponseWriter
+	stats   *database.QueryStats
+	written bool
+	failed  bool
+}
+
+func (w *queryStatsResponseWriter) WriteHeader(code int) {
+	if !w.written {
+		w.setHeaders()
+	}
+	if w.failed {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *queryStatsResponseWriter) Write(b []byte) (int, error) {
+	if !w.written {
+		w.setHeaders()
+	}
+	if w.failed {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *queryStatsResponseWriter) setHeaders() {
+	w.written = true
+	w.Header().Set("X-Query-Count", strconv.Itoa(w.stats.Count()))
+	w.Header().Set("X-Query-Duration", w.stats.Duration().String())
+
+	if err := w.stats.Err(); err != nil {
+		w.failed = true
+		http.Error(w.ResponseWriter, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Unwrap returns the original ResponseWriter, so http.ResponseController can reach it
+func (w *queryStatsResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,64 @@
+package tracks
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmeire/tracks/database"
+)
+
+func TestQueryStats(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotNil(t, database.QueryStatsFromContext(r.Context()))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	t.Run("Adds headers in development", func(t *testing.T) {
+		h, err := QueryStats(Config{Development: true})(handler)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, "0", rec.Header().Get("X-Query-Count"))
+		assert.Equal(t, "0s", rec.Header().Get("X-Query-Duration"))
+	})
+
+	t.Run("No headers in production", func(t *testing.T) {
+		h, err := QueryStats(Config{})(handler)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Empty(t, rec.Header().Get("X-Query-Count"))
+	})
+
+	t.Run("Fails the request on a violation in strict mode", func(t *testing.T) {
+		conf := Config{Development: true, Queries: QueriesConfig{Strict: true, SlowThreshold: "1ns"}}
+		h, err := QueryStats(conf)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			db, err := sql.Open("sqlite3", ":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = database.NewObserved(db).ExecContext(r.Context(), "CREATE TABLE notes (id INTEGER)")
+			var violation *database.QueryViolationError
+			assert.ErrorAs(t, err, &violation)
+
+			// The handler ignores the error, the response is replaced anyway
+			w.WriteHeader(http.StatusCreated)
+		}))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Contains(t, rec.Body.String(), "slow query detected")
+		assert.Equal(t, "1", rec.Header().Get("X-Query-Count"))
+	})
+}
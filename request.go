@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
-	"strconv"
 	"strings"
 )
 
@@ -26,61 +25,19 @@ func ParseRequest(r *http.Request, v any) error {
 	return UnmarshalForm(r.PostForm, v)
 }
 
-// UnmarshalForm populates a struct from form values using 'form' tags.
+// UnmarshalForm populates a struct from form values using 'form' tags. It supports the same keys
+// and field types as Bind, and reports the values that can't be converted as ValidationErrors.
 func UnmarshalForm(values map[string][]string, v any) error {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
 		return fmt.Errorf("v must be a pointer to a struct")
 	}
 
-	rv = rv.Elem()
-	rt := rv.Type()
-
-	for i := 0; i < rt.NumField(); i++ {
-		field := rt.Field(i)
-		tag := field.Tag.Get("form")
-		if tag == "" || tag == "-" {
-			continue
-		}
-
-		val, ok := values[tag]
-		if !ok || len(val) == 0 {
-			continue
-		}
-
-		fieldValue := rv.Field(i)
-		if !fieldValue.CanSet() {
-			continue
-		}
-
-		switch field.Type.Kind() {
-		case reflect.String:
-			fieldValue.SetString(val[0])
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			intVal, err := strconv.ParseInt(val[0], 10, 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse %s as int: %w", tag, err)
-			}
-			fieldValue.SetInt(intVal)
-		case reflect.Bool:
-			boolVal, err := strconv.ParseBool(val[0])
-			if err != nil {
-				return fmt.Errorf("failed to parse %s as bool: %w", tag, err)
-			}
-			fieldValue.SetBool(boolVal)
-		case reflect.Float32, reflect.Float64:
-			floatVal, err := strconv.ParseFloat(val[0], 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse %s as float: %w", tag, err)
-			}
-			fieldValue.SetFloat(floatVal)
-		case reflect.Slice:
-			if field.Type.Elem().Kind() == reflect.String {
-				fieldValue.Set(reflect.ValueOf(val))
-			}
-		}
+	errs := make(ValidationErrors)
+	bindForm(rv.Elem(), "", newFormKeys(values, nil), errs)
+	if len(errs) > 0 {
+		return errs
 	}
-
 	return nil
 }
 
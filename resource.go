@@ -1,6 +1,17 @@
 package tracks
 
-import "net/http"
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
 
 // Resource is an interface for RESTful resources
 type Resource interface {
@@ -19,3 +30,256 @@ type Resource interface {
 	// Destroy destroys an existing resource
 	Destroy(r *http.Request) (any, error)
 }
+
+// ResourceLoader is implemented by resources that load their record for the resources nested under
+// them. The record is loaded once before every nested action, and is available with Parent.
+type ResourceLoader interface {
+	Load(r *http.Request, id string) (any, error)
+}
+
+// resourceActions are the actions of a resource, in the order they're registered
+var resourceActions = []string{"index", "new", "create", "show", "edit", "update", "destroy"}
+
+// ResourceConfig configures the routes of a resource
+type ResourceConfig struct {
+	// Only registers only these actions of the resource, e.g. index and show
+	Only []string
+	// Except registers all actions of the resource except these
+	Except []string
+	// Param is the name of the path value with the ID of the resource, <name>_id by default
+	Param string
+	// Shallow registers the member routes of a nested resource (show, edit, update, destroy and the
+	// Member actions) without the path of its parents, e.g. /invoices/{invoices_id} instead of
+	// /tenants/{tenants_id}/invoices/{invoices_id}
+	Shallow bool
+	// Member are custom actions on a single resource, e.g. POST /invoices/{invoices_id}/send
+	Member []ResourceAction
+	// Collection are custom actions on the collection, e.g. GET /invoices/search
+	Collection []ResourceAction
+	// Nested registers the routes nested under a single resource, e.g. /tenants/{tenants_id}/invoices,
+	// on the router that is passed to it. It returns the router of the last registration, so
+	// registration errors are returned by the parent.
+	Nested func(r Router) Router
+	// Docs document the REST actions in the OpenAPI document, by action name
+	Docs map[string]RouteDoc
+}
+
+// ResourceAction is a custom action of a resource. The name of the action is also its path.
+type ResourceAction struct {
+	Method      string
+	Name        string
+	Func        ActionFunc
+	Layout      string
+	Middlewares []MiddlewareBuilder
+	Doc         *RouteDoc
+}
+
+func (c ResourceConfig) actions() ([]string, error) {
+	for _, a := range slices.Concat(c.Only, c.Except) {
+		if !slices.Contains(resourceActions, a) {
+			return nil, fmt.Errorf("unknown resource action %s", a)
+		}
+	}
+
+	var actions []string
+	for _, a := range resourceActions {
+		if len(c.Only) > 0 && !slices.Contains(c.Only, a) {
+			continue
+		}
+		if slices.Contains(c.Except, a) {
+			continue
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
+
+// resourceName returns the name of a resource, based on its type name without the Resource suffix
+func resourceName(rs any) string {
+	// This little piece of reflection is OK since it only runs once on boot,
+	// it's not a reflection penalty on every request.
+	rt := reflect.TypeOf(rs)
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	return strings.TrimSuffix(strcase.ToSnake(rt.Name()), "_resource")
+}
+
+// ResourceWithConfig registers the REST actions of a resource under path, configured by config
+func (r *router) ResourceWithConfig(rootPath string, rs Resource, config ResourceConfig, mws ...MiddlewareBuilder) Router {
+	actions, err := config.actions()
+	if err != nil {
+		return errRouter{err}
+	}
+
+	name := resourceName(rs)
+	param := config.Param
+	if param == "" {
+		param = name + "_id"
+	}
+
+	basePath := filepath.Join(rootPath, r.normalize(name))
+	memberPath := basePath + "/{" + param + "}"
+
+	// Shallow member routes are registered under the path and middlewares of the outermost parent
+	members := r
+	if config.Shallow && r.shallowRoot != nil {
+		members = r.shallowRoot
+		memberPath = r.normalize(name) + "/{" + param + "}"
+	}
+
+	funcs := map[string]ActionFunc{
+		"index":   rs.Index,
+		"new":     rs.New,
+		"create":  rs.Create,
+		"show":    rs.Show,
+		"edit":    rs.Edit,
+		"update":  rs.Update,
+		"destroy": rs.Destroy,
+	}
+
+	docs := maps.Clone(config.Docs)
+	for _, a := range slices.Concat(config.Collection, config.Member) {
+		if a.Doc != nil {
+			if docs == nil {
+				docs = make(map[string]RouteDoc)
+			}
+			docs[a.Name] = *a.Doc
+		}
+	}
+
+	var nr Router = r
+	register := func(on *router, method, path, action string, a ActionFunc, layout string, mws ...MiddlewareBuilder) bool {
+		nr = on.serve(method, path, name, action, a, layout, mws...)
+		if doc, ok := docs[action]; ok {
+			nr = nr.Document(doc)
+		}
+		_, failed := nr.(errRouter)
+		return !failed
+	}
+
+	for _, action := range actions {
+		var ok bool
+		switch action {
+		case "index":
+			ok = register(r, http.MethodGet, basePath+"/", action, funcs[action], "", mws...)
+		case "new":
+			ok = register(r, http.MethodGet, basePath+"/new", action, funcs[action], "", mws...)
+		case "create":
+			ok = register(r, http.MethodPost, basePath+"/", action, funcs[action], "", mws...)
+		case "show":
+			ok = register(members, http.MethodGet, memberPath, action, funcs[action], "", mws...)
+		case "edit":
+			ok = register(members, http.MethodGet, memberPath+"/edit", action, funcs[action], "", mws...)
+		case "update":
+			ok = register(members, http.MethodPut, memberPath, action, funcs[action], "", mws...) &&
+				register(members, http.MethodPost, memberPath, action, funcs[action], "", mws...)
+		case "destroy":
+			ok = register(members, http.MethodDelete, memberPath, action, funcs[action], "", mws...)
+		}
+		if !ok {
+			return nr
+		}
+	}
+
+	for _, a := range config.Collection {
+		if !register(r, a.Method, basePath+"/"+a.Name, a.Name, a.Func, a.Layout, slices.Concat(mws, a.Middlewares)...) {
+			return nr
+		}
+	}
+	for _, a := range config.Member {
+		if !register(members, a.Method, memberPath+"/"+a.Name, a.Name, a.Func, a.Layout, slices.Concat(mws, a.Middlewares)...) {
+			return nr
+		}
+	}
+
+	if nr, needsRouter := rs.(interface {
+		Inject(r Router)
+	}); needsRouter {
+		nr.Inject(r)
+	}
+
+	subresources, hasSubresources := rs.(interface {
+		Subresources() []Resource
+	})
+	subcontrollers, hasSubcontrollers := rs.(interface {
+		Subcontrollers() []Controller
+	})
+	if !hasSubresources && !hasSubcontrollers && config.Nested == nil {
+		return nr
+	}
+
+	// Nested routes are registered on a group under the path of a single resource
+	nested := r.group(basePath+"/{"+param+"}", GroupConfig{Middlewares: mws})
+	if nested.shallowRoot == nil {
+		nested.shallowRoot = r.group(rootPath, GroupConfig{Middlewares: mws})
+	}
+	if loader, ok := rs.(ResourceLoader); ok {
+		nested.parents = append(slices.Clone(r.parents), parentLoader{param: param, loader: loader})
+	}
+
+	var sub Router = nested
+	if hasSubresources {
+		for _, sr := range subresources.Subresources() {
+			sub = sub.ResourceAtPath("/", sr)
+		}
+	}
+	if hasSubcontrollers {
+		for _, sc := range subcontrollers.Subcontrollers() {
+			sub = sub.ControllerAtPath("/", sc)
+		}
+	}
+	if _, failed := sub.(errRouter); failed {
+		return sub
+	}
+	if config.Nested != nil {
+		if sub, failed := config.Nested(nested).(errRouter); failed {
+			return sub
+		}
+	}
+	return nr
+}
+
+// parentLoader loads the parent of a nested resource from the path value param
+type parentLoader struct {
+	param  string
+	loader ResourceLoader
+}
+
+type parentsKey struct{}
+
+// withParents wraps an action of a nested resource to load its parents first
+func (r *router) withParents(a ActionFunc) ActionFunc {
+	parents := r.parents
+	return func(req *http.Request) (any, error) {
+		loaded := slices.Clone(parentsFromContext(req.Context()))
+		for _, p := range parents {
+			v, err := p.loader.Load(req, req.PathValue(p.param))
+			if err != nil {
+				return nil, err
+			}
+			loaded = append(loaded, v)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), parentsKey{}, loaded))
+		return a(req)
+	}
+}
+
+func parentsFromContext(ctx context.Context) []any {
+	parents, _ := ctx.Value(parentsKey{}).([]any)
+	return parents
+}
+
+// Parent returns the closest parent of type T of a nested resource, loaded by a ResourceLoader:
+//
+//	tenant, ok := tracks.Parent[*Tenant](r)
+func Parent[T any](r *http.Request) (T, bool) {
+	parents := parentsFromContext(r.Context())
+	for i := len(parents) - 1; i >= 0; i-- {
+		if v, ok := parents[i].(T); ok {
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
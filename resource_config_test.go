@@ -0,0 +1,204 @@
+package tracks
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Tenant struct {
+	ID   string
+	Name string
+}
+
+// TenantsResource loads its tenant for the nested resources
+type TenantsResource struct {
+	ProductResource
+	loads int
+}
+
+func (t *TenantsResource) Load(r *http.Request, id string) (any, error) {
+	t.loads++
+	if id == "missing" {
+		return nil, AppError{StatusCode: http.StatusNotFound, Message: "tenant not found"}
+	}
+	return &Tenant{ID: id, Name: "Tenant " + id}, nil
+}
+
+type InvoicesResource struct {
+	ProductResource
+}
+
+func (i InvoicesResource) Show(r *http.Request) (any, error) {
+	tenant, ok := Parent[*Tenant](r)
+	if !ok {
+		return "invoice " + r.PathValue("id"), nil
+	}
+	return fmt.Sprintf("%s invoice %s", tenant.Name, r.PathValue("id")), nil
+}
+
+func (i InvoicesResource) Index(r *http.Request) (any, error) {
+	tenant, _ := Parent[*Tenant](r)
+	return "invoices of " + tenant.Name + " (" + r.PathValue("tenants_id") + ")", nil
+}
+
+func routePatterns(r Router) []string {
+	var patterns []string
+	for _, route := range r.Routes() {
+		patterns = append(patterns, route.Method+" "+route.Path)
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+func TestResourceConfig(t *testing.T) {
+	noop := func(r *http.Request) (any, error) { return "ok", nil }
+
+	t.Run("Only and except filter the actions", func(t *testing.T) {
+		r := New(t.Context()).
+			ResourceWithConfig("/", ProductResource{}, ResourceConfig{Only: []string{"index", "show"}}).
+			ResourceWithConfig("/", InvoicesResource{}, ResourceConfig{Except: []string{"new", "edit", "update"}})
+
+		assert.Equal(t, []string{
+			"DELETE /invoices/{invoices_id}",
+			"GET /invoices/",
+			"GET /invoices/{invoices_id}",
+			"GET /product/",
+			"GET /product/{product_id}",
+			"POST /invoices/",
+		}, routePatterns(r))
+	})
+
+	t.Run("Unknown actions fail", func(t *testing.T) {
+		r := New(t.Context()).ResourceWithConfig("/", ProductResource{}, ResourceConfig{Only: []string{"list"}})
+		_, err := r.Handler()
+		assert.Error(t, err)
+	})
+
+	t.Run("Custom member and collection actions", func(t *testing.T) {
+		r := New(t.Context()).ResourceWithConfig("/", InvoicesResource{}, ResourceConfig{
+			Only:       []string{"show"},
+			Param:      "id",
+			Member:     []ResourceAction{{Method: http.MethodPost, Name: "send", Func: noop}},
+			Collection: []ResourceAction{{Method: http.MethodGet, Name: "search", Func: noop}},
+		})
+
+		assert.Equal(t, []string{
+			"GET /invoices/search",
+			"GET /invoices/{id}",
+			"POST /invoices/{id}/send",
+		}, routePatterns(r))
+
+		u, err := r.URLFor("invoices#send", "id", 4)
+		require.NoError(t, err)
+		assert.Equal(t, "/invoices/4/send", u)
+	})
+}
+
+func TestNestedResource(t *testing.T) {
+	tenants := &TenantsResource{}
+
+	r := New(t.Context())
+	r.Group("/admin").ResourceWithConfig("/", tenants, ResourceConfig{
+		Only: []string{"show"},
+		Nested: func(r Router) Router {
+			return r.
+				ResourceWithConfig("/", InvoicesResource{}, ResourceConfig{Only: []string{"index", "show"}, Param: "id"}).
+				ResourceWithConfig("/", ProductResource{}, ResourceConfig{Only: []string{"index", "show"}, Shallow: true})
+		},
+	})
+
+	assert.Equal(t, []string{
+		"GET /admin/product/{product_id}",
+		"GET /admin/tenants/{tenants_id}",
+		"GET /admin/tenants/{tenants_id}/invoices/",
+		"GET /admin/tenants/{tenants_id}/invoices/{id}",
+		"GET /admin/tenants/{tenants_id}/product/",
+	}, routePatterns(r))
+
+	h, err := r.Handler()
+	require.NoError(t, err)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Loads the parent for nested actions", func(t *testing.T) {
+		rr := serve("/admin/tenants/acme/invoices/7")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `"Tenant acme invoice 7"`, rr.Body.String())
+
+		rr = serve("/admin/tenants/acme/invoices/")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `"invoices of Tenant acme (acme)"`, rr.Body.String())
+	})
+
+	t.Run("Fails when the parent can't be loaded", func(t *testing.T) {
+		rr := serve("/admin/tenants/missing/invoices/7")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Doesn't load the parent for the parent itself or shallow routes", func(t *testing.T) {
+		before := tenants.loads
+		assert.Equal(t, http.StatusOK, serve("/admin/tenants/acme").Code)
+		assert.Equal(t, http.StatusOK, serve("/admin/product/1").Code)
+		assert.Equal(t, before, tenants.loads)
+	})
+
+	t.Run("Nested registration errors are returned by the parent", func(t *testing.T) {
+		r := New(t.Context()).ResourceWithConfig("/", &TenantsResource{}, ResourceConfig{
+			Only: []string{"show"},
+			Nested: func(r Router) Router {
+				return r.ResourceWithConfig("/", InvoicesResource{}, ResourceConfig{Only: []string{"bogus"}})
+			},
+		})
+		_, err := r.Handler()
+		assert.Error(t, err)
+	})
+
+	t.Run("Parent is empty outside of nested resources", func(t *testing.T) {
+		_, ok := Parent[*Tenant](httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.False(t, ok)
+	})
+}
+
+func TestNestedResourceScope(t *testing.T) {
+	r := New(t.Context())
+	r.ResourceWithConfig("/api", &TenantsResource{}, ResourceConfig{
+		Only: []string{"show"},
+		Nested: func(r Router) Router {
+			return r.
+				ResourceWithConfig("/", InvoicesResource{}, ResourceConfig{Only: []string{"show"}, Param: "id"}).
+				ResourceWithConfig("/", ProductResource{}, ResourceConfig{Only: []string{"index", "show"}, Shallow: true})
+		},
+	}, tag("tenants"))
+
+	assert.Equal(t, []string{
+		"GET /api/product/{product_id}",
+		"GET /api/tenants/{tenants_id}",
+		"GET /api/tenants/{tenants_id}/invoices/{id}",
+		"GET /api/tenants/{tenants_id}/product/",
+	}, routePatterns(r))
+
+	h, err := r.Handler()
+	require.NoError(t, err)
+
+	for _, path := range []string{"/api/tenants/acme", "/api/tenants/acme/invoices/7", "/api/tenants/acme/product/", "/api/product/1"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code, path)
+		assert.Equal(t, []string{"tenants"}, rr.Header().Values("X-Trace"), path)
+	}
+}
@@ -11,16 +11,13 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
-	"path/filepath"
-	"reflect"
+	"slices"
 	"strings"
 	"syscall"
 
 	"github.com/tmeire/tracks/database"
 	"github.com/tmeire/tracks/i18n"
 	"github.com/tmeire/tracks/otel"
-
-	"github.com/iancoleman/strcase"
 )
 
 type Router interface {
@@ -41,11 +38,14 @@ type Router interface {
 	LogHostEntriesWithMessage(message string) Router
 	HealthCheck(path string, config ...HealthConfig) Router
 	Version(v string, config ...VersionConfig) Router
+	Group(prefix string, config ...GroupConfig) Router
 	VersionFromHeader(header, value string, r Router) Router
 	VersionFromQuery(param, value string, r Router) Router
 	RateLimit(config RateLimitConfig) Router
 	WebSocket(path string, handler WebSocketHandler, mws ...MiddlewareBuilder) Router
 	CSRFProtection(config CSRFConfig) Router
+	CORS(config CORSConfig) Router
+	SecurityHeaders(config SecurityConfig) Router
 	Cache() Cache
 	WithCache(c Cache) Router
 	Queue() Queue
@@ -54,6 +54,10 @@ type Router interface {
 	Page(path string, view string) Router
 	Redirect(origin string, destination string) Router
 	Serve(a Action) Router
+	As(name string) Router
+	URLFor(name string, params ...any) (string, error)
+	Routes() []Route
+	Document(doc RouteDoc) Router
 	Controller(c Controller, mws ...MiddlewareBuilder) Router
 	ControllerAtPath(path string, c Controller, mws ...MiddlewareBuilder) Router
 	Get(path string, controller, action string, r ActionController, mws ...MiddlewareBuilder) Router
@@ -64,6 +68,7 @@ type Router interface {
 	DeleteFunc(path string, controller, action string, r ActionFunc, mws ...MiddlewareBuilder) Router
 	Resource(r Resource, mws ...MiddlewareBuilder) Router
 	ResourceAtPath(path string, r Resource, mws ...MiddlewareBuilder) Router
+	ResourceWithConfig(path string, r Resource, config ResourceConfig, mws ...MiddlewareBuilder) Router
 	SkipDefaultMiddlewares() Router
 	Templates() *Templates
 	Config() Config
@@ -91,8 +96,23 @@ type router struct {
 	requestMiddlewares *middlewares
 	isClone            bool
 	templates          *Templates
+	routes             *routeTable
+	cors               *corsPolicies
+	security           *securityHeaders
+	name               string
 	translator         *i18n.Translator
 	shutdownOtel       otel.Shutdown
+
+	// The scope of a group, applied to the routes registered on it
+	isGroup     bool
+	base        *router
+	prefix      string
+	layout      string
+	middlewares []MiddlewareBuilder
+	version     string
+	deprecated  bool
+	parents     []parentLoader
+	shallowRoot *router
 }
 
 // New creates a new router with a database-backed session store
@@ -119,6 +139,24 @@ func NewFromConfig(ctx context.Context, conf Config) Router {
 		return errRouter{err: err}
 	}
 
+	// Record the queries of every request
+	db = database.NewObserved(db)
+
+	if conf.Secrets.Cursor != "" {
+		database.SetCursorSecret([]byte(conf.Secrets.Cursor))
+	}
+
+	if len(conf.Secrets.Encryption) > 0 {
+		keys := make([][]byte, len(conf.Secrets.Encryption))
+		for i, k := range conf.Secrets.Encryption {
+			keys[i] = []byte(k)
+		}
+		if err := database.SetEncryptionKeys(keys...); err != nil {
+			slog.ErrorContext(ctx, "Failed to set the encryption keys", "error", err)
+			return errRouter{err: err}
+		}
+	}
+
 	// Initialize the translator with English as the default language
 	translator := i18n.NewTranslator("en")
 
@@ -157,11 +195,24 @@ func NewFromConfig(ctx context.Context, conf Config) Router {
 		translator:         translator,
 		shutdownOtel:       shutdownOtel,
 		templates:          newTemplates(conf.BaseDomain),
+		routes:             newRouteTable(),
+		cors:               &corsPolicies{},
+		name:               "root",
 	}
 
 	// HTTP traces for every request
 	r.GlobalMiddleware(otel.Trace)
 
+	// Count the queries of every request and detect N+1 and slow queries in development
+	r.GlobalMiddleware(QueryStats(conf))
+
+	// Reads go to the primary after the first write of a request, so it reads its own writes
+	r.GlobalMiddleware(func(next http.Handler) (http.Handler, error) {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(database.WithReadYourWrites(r.Context())))
+		}), nil
+	})
+
 	// Inject queue into context
 	if q != nil {
 		r.GlobalMiddleware(func(next http.Handler) (http.Handler, error) {
@@ -188,9 +239,17 @@ func NewFromConfig(ctx context.Context, conf Config) Router {
 	// Catch all panics to make sure no weird output is written to the client
 	r.GlobalMiddleware(CatchAll)
 
+	// Set the security headers and the nonce of the Content-Security-Policy
+	r.SecurityHeaders(conf.Security)
+
 	// Serving static files on the root domain
 	r.static("/assets/", "public")
 
+	// Serving the OpenAPI document of the routes
+	if conf.OpenAPI.Path != "" {
+		r.mux.Handle("GET "+conf.OpenAPI.Path, r.openAPIHandler())
+	}
+
 	r.GlobalMiddleware(database.Middleware(db))
 
 	// Set up i18n middleware for language detection
@@ -226,6 +285,9 @@ func NewFromConfig(ctx context.Context, conf Config) Router {
 	}
 	r.GlobalMiddleware(sessionMW)
 
+	// Attribute the changes to audited models to the session user and request
+	r.GlobalMiddleware(AuditActor)
+
 	return r
 }
 
@@ -247,7 +309,16 @@ func (r *router) Clone() Router {
 			l: r.requestMiddlewares.l,
 		},
 		isClone:      true,
+		prefix:       r.prefix,
+		layout:       r.layout,
+		middlewares:  slices.Clone(r.middlewares),
+		version:      r.version,
+		deprecated:   r.deprecated,
+		parents:      slices.Clone(r.parents),
 		templates:    templates,
+		routes:       r.routes,
+		cors:         &corsPolicies{},
+		name:         r.routes.clone(),
 		translator:   r.translator,
 		shutdownOtel: r.shutdownOtel,
 	}
@@ -307,21 +378,41 @@ const defaultLayout = "application"
 // - path: the URL path for which the handler should be registered.
 // - r: the ActionFunc function that handles HTTP requests.
 func (r *router) serve(method, urlPath string, controller, action string, a ActionFunc, layout string, mws ...MiddlewareBuilder) Router {
-	normalizedPath := r.normalize(urlPath)
+	normalizedPath := r.normalize(r.path(urlPath))
 
 	pattern := method + " " + normalizedPath
-	fmt.Printf("DEBUG: Registering route: %s (Controller: %s, Action: %s, Router: %p)\n", pattern, controller, action, r)
+	slog.Debug("Registering route", "pattern", pattern, "controller", controller, "action", action, "router", r.name)
 
+	if layout == "" {
+		layout = r.layout
+	}
 	if layout == "" {
 		layout = defaultLayout
 	}
+	if len(r.middlewares) > 0 {
+		mws = append(slices.Clone(r.middlewares), mws...)
+	}
 
 	tpl, err := r.templates.Load(layout, controller, action)
 	if err != nil {
 		return errRouter{err}
 	}
 
-	h, err := r.requestMiddlewares.Wrap(r, a.wrap(controller, action, tpl, r.translator), mws...)
+	if len(r.parents) > 0 {
+		a = r.withParents(a)
+	}
+
+	act := a.wrap(controller, action, tpl, r.translator)
+	names := make([]string, 0, len(r.requestMiddlewares.l)+len(mws))
+	for _, m := range r.requestMiddlewares.l {
+		names = append(names, middlewareName(m))
+	}
+	for _, m := range mws {
+		names = append(names, middlewareName(m))
+	}
+	act.route = r.routes.add(r, method, normalizedPath, controller, action, layout, names)
+
+	h, err := r.requestMiddlewares.Wrap(r, act, mws...)
 	if err != nil {
 		return errRouter{err}
 	}
@@ -345,7 +436,8 @@ func (r *router) Static(urlPath, dir string) Router {
 
 // StaticWithConfig registers a directory or file to serve static files from with additional configuration.
 func (r *router) StaticWithConfig(urlPath, dir string, config StaticConfig) Router {
-	// Ensure the path starts with a slash
+	// Ensure the path starts with a slash, and add the prefix of the group
+	urlPath = r.path(urlPath)
 	if !strings.HasPrefix(urlPath, "/") {
 		urlPath = "/" + urlPath
 	}
@@ -390,6 +482,12 @@ func (r *router) StaticWithConfig(urlPath, dir string, config StaticConfig) Rout
 		})
 	}
 
+	// Apply the middlewares of the group
+	handler, err = (&middlewares{}).Wrap(r, handler, r.middlewares...)
+	if err != nil {
+		return errRouter{err}
+	}
+
 	// Register the handler for the URL path
 	r.mux.Handle(http.MethodGet+" "+urlPath, handler)
 
@@ -554,10 +652,19 @@ func (r *router) CSRFProtection(config CSRFConfig) Router {
 }
 
 func (r *router) Cache() Cache {
+	if r.base != nil {
+		return r.base.Cache()
+	}
 	return r.cache
 }
 
+// WithCache sets the cache of the router. On a group, it sets the cache of the router the group was
+// created from, like the other shared state.
 func (r *router) WithCache(c Cache) Router {
+	if r.base != nil {
+		r.base.WithCache(c)
+		return r
+	}
 	r.cache = c
 	return r
 }
@@ -567,6 +674,13 @@ func (r *router) Queue() Queue {
 }
 
 func (r *router) RequestMiddleware(m Middleware) Router {
+	if r.isGroup {
+		// The request middlewares of a group only apply to its own routes
+		if m != nil {
+			r.middlewares = append(r.middlewares, func(Router) Middleware { return m })
+		}
+		return r
+	}
 	r.requestMiddlewares.Apply(m)
 	return r
 }
@@ -613,13 +727,31 @@ func (r *router) Page(path string, view string) Router {
 	})
 }
 
+// Redirect permanently redirects the requests for origin, a path or a ServeMux pattern, to
+// destination. On a group, origin gets the prefix and middlewares of the group.
 func (r *router) Redirect(origin string, destination string) Router {
-	r.mux.Handle(origin, http.RedirectHandler(destination, http.StatusMovedPermanently))
+	if r.isGroup {
+		if method, p, ok := strings.Cut(origin, " "); ok {
+			origin = method + " " + r.path(p)
+		} else {
+			origin = r.path(origin)
+		}
+	}
+
+	h, err := (&middlewares{}).Wrap(r, http.RedirectHandler(destination, http.StatusMovedPermanently), r.middlewares...)
+	if err != nil {
+		return errRouter{err}
+	}
+	r.mux.Handle(origin, h)
 	return r
 }
 
 func (r *router) Serve(a Action) Router {
-	return r.serve(a.Method, a.Path, a.Controller, a.Name, a.Func, a.Layout, a.Middlewares...)
+	nr := r.serve(a.Method, a.Path, a.Controller, a.Name, a.Func, a.Layout, a.Middlewares...)
+	if a.Doc != nil {
+		return nr.Document(*a.Doc)
+	}
+	return nr
 }
 
 func (r *router) Controller(c Controller, mws ...MiddlewareBuilder) Router {
@@ -651,7 +783,7 @@ func (r *router) Get(path string, controller, action string, c ActionController,
 	}); needsRouter {
 		nr.Inject(r)
 	}
-	return r.serve(http.MethodGet, path, controller, action, c.Index, defaultLayout, mws...)
+	return r.serve(http.MethodGet, path, controller, action, c.Index, "", mws...)
 }
 
 // GetFunc registers a handler for HTTP GET requests to the specified path.
@@ -660,7 +792,7 @@ func (r *router) Get(path string, controller, action string, c ActionController,
 // - path: the URL path for which the handler should be registered.
 // - r: the ActionFunc to handle the HTTP GET request.
 func (r *router) GetFunc(path string, controller, action string, a ActionFunc, mws ...MiddlewareBuilder) Router {
-	return r.serve(http.MethodGet, path, controller, action, a, defaultLayout, mws...)
+	return r.serve(http.MethodGet, path, controller, action, a, "", mws...)
 }
 
 // PostFunc registers a handler for HTTP POST requests to the specified path.
@@ -669,7 +801,7 @@ func (r *router) GetFunc(path string, controller, action string, a ActionFunc, m
 // - path: the URL path for which the handler should be registered.
 // - r: the ActionFunc to handle the HTTP POST request.
 func (r *router) PostFunc(path string, controller, action string, a ActionFunc, mws ...MiddlewareBuilder) Router {
-	return r.serve(http.MethodPost, path, controller, action, a, defaultLayout, mws...)
+	return r.serve(http.MethodPost, path, controller, action, a, "", mws...)
 }
 
 // PutFunc registers a handler for HTTP PUT requests to the specified path.
@@ -678,7 +810,7 @@ func (r *router) PostFunc(path string, controller, action string, a ActionFunc,
 // - path: the URL path for which the handler should be registered.
 // - r: the ActionFunc to handle the HTTP PUT request.
 func (r *router) PutFunc(path string, controller, action string, a ActionFunc, mws ...MiddlewareBuilder) Router {
-	return r.serve(http.MethodPut, path, controller, action, a, defaultLayout, mws...)
+	return r.serve(http.MethodPut, path, controller, action, a, "", mws...)
 }
 
 // PatchFunc registers a handler for HTTP PATCH requests to the specified path.
@@ -687,7 +819,7 @@ func (r *router) PutFunc(path string, controller, action string, a ActionFunc, m
 // - path: the URL path for which the handler should be registered.
 // - r: the ActionFunc to handle the HTTP PATCH request.
 func (r *router) PatchFunc(path string, controller, action string, a ActionFunc, mws ...MiddlewareBuilder) Router {
-	return r.serve(http.MethodPatch, path, controller, action, a, defaultLayout, mws...)
+	return r.serve(http.MethodPatch, path, controller, action, a, "", mws...)
 }
 
 // DeleteFunc registers a handler for HTTP DELETE requests to the specified path.
@@ -696,7 +828,7 @@ func (r *router) PatchFunc(path string, controller, action string, a ActionFunc,
 // - path: the URL path for which the handler should be registered.
 // - r: the ActionFunc to handle the HTTP DELETE request.
 func (r *router) DeleteFunc(path string, controller, action string, a ActionFunc, mws ...MiddlewareBuilder) Router {
-	return r.serve(http.MethodDelete, path, controller, action, a, defaultLayout, mws...)
+	return r.serve(http.MethodDelete, path, controller, action, a, "", mws...)
 }
 
 // Resource registers a resourceful route for a given resource and tasks it with handling
@@ -724,60 +856,7 @@ func (r *router) Resource(rs Resource, mws ...MiddlewareBuilder) Router {
 }
 
 func (r *router) ResourceAtPath(rootPath string, rs Resource, mws ...MiddlewareBuilder) Router {
-	// This little piece of reflection is OK since it only runs once on boot,
-	// it's not a reflection penalty on every request.
-	rt := reflect.TypeOf(rs)
-	if rt.Kind() == reflect.Ptr {
-		rt = rt.Elem()
-	}
-	name := strcase.ToSnake(rt.Name())
-	if strings.HasSuffix(name, "_resource") {
-		name = strings.TrimSuffix(name, "_resource")
-	}
-
-	pathParamName := fmt.Sprintf(`{%s_id}`, name)
-
-	basePath := filepath.Join(rootPath, r.normalize(name))
-
-	// Register resource actions with the controller name
-	nr := r.GetFunc(basePath+"/", name, "index", rs.Index, mws...).
-		GetFunc(basePath+"/new", name, "new", rs.New, mws...).
-		PostFunc(basePath+"/", name, "create", rs.Create, mws...).
-		GetFunc(basePath+"/"+pathParamName, name, "show", rs.Show, mws...).
-		GetFunc(basePath+"/"+pathParamName+"/edit", name, "edit", rs.Edit, mws...).
-		PutFunc(basePath+"/"+pathParamName, name, "update", rs.Update, mws...).
-		PostFunc(basePath+"/"+pathParamName, name, "update", rs.Update, mws...).
-		DeleteFunc(basePath+"/"+pathParamName, name, "destroy", rs.Destroy, mws...)
-
-	if nr, needsRouter := rs.(interface {
-		Inject(r Router)
-	}); needsRouter {
-		nr.Inject(r)
-	}
-
-	// If this resource has subresources, register these as well.
-	if withSubresouces, ok := rs.(interface {
-		Subresources() []Resource
-	}); ok {
-		basePath = fmt.Sprintf("%s/{%s_id}/", basePath, name)
-
-		for _, sr := range withSubresouces.Subresources() {
-			nr = nr.ResourceAtPath(basePath, sr)
-		}
-	}
-
-	// If this resource has subcontrollers, register these as well.
-	if withSubcontrollers, ok := rs.(interface {
-		Subcontrollers() []Controller
-	}); ok {
-		basePath = fmt.Sprintf("%s/{%s_id}/", basePath, name)
-
-		for _, sr := range withSubcontrollers.Subcontrollers() {
-			nr = nr.ControllerAtPath(basePath, sr)
-		}
-	}
-
-	return nr
+	return r.ResourceWithConfig(rootPath, rs, ResourceConfig{}, mws...)
 }
 
 // Handler creates an HTTP handler for this router that can be used to launch
@@ -790,15 +869,52 @@ func (r *router) Config() Config {
 }
 
 func (r *router) Handler() (http.Handler, error) {
-	return r.globalMiddlewares.Wrap(r, r.mux)
+	// Forms can only POST, the method override has to run before the route is matched
+	mux, err := MethodOverride(r.mux)
+	if err != nil {
+		return nil, err
+	}
+	return r.globalMiddlewares.Wrap(r, mux)
+}
+
+// Main is the entrypoint of an application. It runs the router like Run, unless the application was
+// started by `tracks db seed`: it seeds the database and returns instead.
+//
+//	func main() {
+//		r := tracks.New(ctx).Resource(&PostsResource{})
+//		if err := tracks.Main(ctx, r); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+func Main(ctx context.Context, r Router) error {
+	rt, ok := r.(*router)
+	if !ok {
+		return r.Run(ctx)
+	}
+	for rt.parent != nil {
+		rt = rt.parent
+	}
+
+	if dbType := os.Getenv("TRACKS_SEED"); dbType != "" {
+		return rt.seedFromEnv(ctx, database.Type(dbType))
+	}
+	return rt.Run(ctx)
 }
 
 // Run starts the HTTP server using the router as the handler on the specified port or default port 8080 if unset.
 // It retrieves the port from the PORT environment variable and logs the server address before starting it.
+// When started by `tracks routes` or `tracks openapi`, it writes the routes or the OpenAPI document
+// and returns instead.
 func (r *router) Run(ctx context.Context) error {
 	if r.parent != nil {
 		return r.parent.Run(ctx)
 	}
+	if path := os.Getenv("TRACKS_ROUTES"); path != "" {
+		return r.writeRoutes(path)
+	}
+	if path := os.Getenv("TRACKS_OPENAPI"); path != "" {
+		return r.writeOpenAPI(path)
+	}
 	h, err := r.Handler()
 	if err != nil {
 		return err
@@ -1040,6 +1156,38 @@ func (e errRouter) ResourceAtPath(path string, r Resource, mws ...MiddlewareBuil
 	return e
 }
 
+func (e errRouter) Group(prefix string, config ...GroupConfig) Router {
+	return e
+}
+
+func (e errRouter) As(name string) Router {
+	return e
+}
+
+func (e errRouter) URLFor(name string, params ...any) (string, error) {
+	return "", e.err
+}
+
+func (e errRouter) Routes() []Route {
+	return nil
+}
+
+func (e errRouter) SecurityHeaders(config SecurityConfig) Router {
+	return e
+}
+
+func (e errRouter) CORS(config CORSConfig) Router {
+	return e
+}
+
+func (e errRouter) Document(doc RouteDoc) Router {
+	return e
+}
+
+func (e errRouter) ResourceWithConfig(path string, r Resource, config ResourceConfig, mws ...MiddlewareBuilder) Router {
+	return e
+}
+
 func (e errRouter) SkipDefaultMiddlewares() Router {
 	return e
 }
@@ -20,6 +20,7 @@ func TestRouter_Config(t *testing.T) {
 	r := &router{
 		config:             conf,
 		requestMiddlewares: &middlewares{},
+		routes:             newRouteTable(),
 	}
 
 	assert.Equal(t, conf, r.Config())
@@ -0,0 +1,365 @@
+package tracks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrRouteNotFound is returned when a URL is generated for a route name that isn't registered
+var ErrRouteNotFound = errors.New("route not found")
+
+// Route is a route registered on the router
+type Route struct {
+	// Name is the name of the route, used to generate its URL. It defaults to controller#action.
+	Name string `json:"name"`
+	// Method is the HTTP method of the route
+	Method string `json:"method"`
+	// Path is the path pattern of the route, including the version prefix
+	Path string `json:"path"`
+	// Controller and Action handle the route
+	Controller string `json:"controller"`
+	Action     string `json:"action"`
+	// Layout is the layout the HTML responses of the route are rendered in
+	Layout string `json:"layout"`
+	// Middlewares are the names of the request and route middlewares, in the order they run
+	Middlewares []string `json:"middlewares"`
+	// Router is the router the route is registered on, root or the name of a clone
+	Router string `json:"router"`
+	// Version is the API version of the route, empty for routes outside of a version
+	Version string `json:"version"`
+	// Deprecated is set for the routes of a deprecated version
+	Deprecated bool `json:"deprecated"`
+	// Request and Response are the Go types of the request and response of the route, when documented
+	Request  string `json:"request,omitempty"`
+	Response string `json:"response,omitempty"`
+	// Doc documents the route in the OpenAPI document
+	Doc RouteDoc `json:"-"`
+
+	router *router
+}
+
+// routeTable is the list of routes that is shared by a router and its clones, so URLs can be
+// generated for routes that are served on a different (sub)domain.
+type routeTable struct {
+	mu     sync.RWMutex
+	routes []*Route
+	clones int
+}
+
+func newRouteTable() *routeTable {
+	return &routeTable{}
+}
+
+// clone returns the name of a new clone of the router
+func (t *routeTable) clone() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clones++
+	return fmt.Sprintf("clone-%d", t.clones)
+}
+
+// add records a route of router r and returns it
+func (t *routeTable) add(r *router, method, path, controller, action, layout string, mws []string) *Route {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	route := &Route{
+		Name:        controller + "#" + action,
+		Method:      method,
+		Path:        path,
+		Controller:  controller,
+		Action:      action,
+		Layout:      layout,
+		Middlewares: mws,
+		Router:      r.name,
+		Version:     r.version,
+		Deprecated:  r.deprecated,
+		router:      r,
+	}
+	t.routes = append(t.routes, route)
+	return route
+}
+
+// list returns a copy of the routes, sorted by path and method
+func (t *routeTable) list() []Route {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	res := make([]Route, len(t.routes))
+	for i, r := range t.routes {
+		res[i] = *r
+		res[i].Middlewares = slices.Clone(r.Middlewares)
+	}
+	slices.SortStableFunc(res, func(a, b Route) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return res
+}
+
+// rename changes the name of the route that was registered last
+func (t *routeTable) rename(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.routes) == 0 {
+		return errors.New("no route registered to name")
+	}
+	t.routes[len(t.routes)-1].Name = name
+	return nil
+}
+
+// lookup finds the route with the given name. When several routes have the same name, e.g. the
+// same controller in two API versions or on a tenant router, the route of the given router and
+// version is preferred.
+func (t *routeTable) lookup(name string, r *router, version string) (*Route, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var found *Route
+	best := -1
+	for _, route := range t.routes {
+		if route.Name != name {
+			continue
+		}
+		score := 0
+		if route.Router == r.name {
+			score += 2
+		}
+		if route.Version == version {
+			score++
+		}
+		if score > best {
+			found, best = route, score
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRouteNotFound, name)
+	}
+	return found, nil
+}
+
+// URL builds the path of the route. The params fill the path values of the route, the params that
+// aren't part of the path are added to the query string.
+func (rt *Route) URL(params map[string]string) (string, error) {
+	var b strings.Builder
+	used := make(map[string]bool)
+
+	path := rt.Path
+	for {
+		start := strings.Index(path, "{")
+		if start < 0 {
+			b.WriteString(path)
+			break
+		}
+		end := strings.Index(path[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("invalid path pattern %q of route %s", rt.Path, rt.Name)
+		}
+		end += start
+
+		b.WriteString(path[:start])
+		key := path[start+1 : end]
+		path = path[end+1:]
+
+		// {$} only matches the end of the path
+		if key == "$" {
+			continue
+		}
+
+		wildcard := strings.HasSuffix(key, "...")
+		key = strings.TrimSuffix(key, "...")
+
+		value, ok := params[key]
+		if !ok {
+			return "", fmt.Errorf("missing path value %s for route %s", key, rt.Name)
+		}
+		used[key] = true
+
+		if wildcard {
+			// Wildcards match the rest of the path, keep the slashes
+			segments := strings.Split(value, "/")
+			for i, s := range segments {
+				segments[i] = url.PathEscape(s)
+			}
+			b.WriteString(strings.Join(segments, "/"))
+		} else {
+			b.WriteString(url.PathEscape(value))
+		}
+	}
+
+	query := url.Values{}
+	for k, v := range params {
+		if !used[k] {
+			query.Set(k, v)
+		}
+	}
+	if len(query) > 0 {
+		return b.String() + "?" + query.Encode(), nil
+	}
+	return b.String(), nil
+}
+
+// routeParams turns the params passed to URLFor into a map. The params are key/value pairs, or a
+// single map. Models are replaced by their ID.
+func routeParams(params ...any) (map[string]string, error) {
+	res := make(map[string]string)
+
+	if len(params) == 1 {
+		switch m := params[0].(type) {
+		case map[string]any:
+			for k, v := range m {
+				res[k] = routeParam(v)
+			}
+			return res, nil
+		case map[string]string:
+			for k, v := range m {
+				res[k] = v
+			}
+			return res, nil
+		case url.Values:
+			for k := range m {
+				res[k] = m.Get(k)
+			}
+			return res, nil
+		}
+	}
+
+	if len(params)%2 != 0 {
+		return nil, errors.New("route params must be key/value pairs")
+	}
+	for i := 0; i < len(params); i += 2 {
+		key, ok := params[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("route param keys must be strings, got %T", params[i])
+		}
+		res[key] = routeParam(params[i+1])
+	}
+	return res, nil
+}
+
+func routeParam(v any) string {
+	if m, ok := v.(interface{ GetID() any }); ok {
+		v = m.GetID()
+	}
+	return fmt.Sprint(v)
+}
+
+// urlFor builds the path of the named route, as seen from router r in the given version
+func (r *router) urlFor(version, name string, params ...any) (string, error) {
+	route, err := r.routes.lookup(name, r, version)
+	if err != nil {
+		return "", err
+	}
+	p, err := routeParams(params...)
+	if err != nil {
+		return "", err
+	}
+	return route.URL(p)
+}
+
+// URLFor returns the path of the route with the given name, e.g.
+//
+//	r.URLFor("users#show", "users_id", 5, "tab", "posts") // /users/5?tab=posts
+//
+// The params are key/value pairs or a map. They fill the path values of the route, the other
+// params are added to the query string.
+func (r *router) URLFor(name string, params ...any) (string, error) {
+	return r.urlFor(r.version, name, params...)
+}
+
+// Routes returns all routes of the application, including the routes of the clones of the router
+// and of API versions, sorted by path and method
+func (r *router) Routes() []Route {
+	return r.routes.list()
+}
+
+// writeRoutes writes the routes to a JSON file, for `tracks routes`
+func (r *router) writeRoutes(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(r.Routes())
+}
+
+// middlewareName returns the name of the function of a middleware, without its package path
+func middlewareName(fn any) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, "-fm")
+
+	// Name closures after the function that returns them
+	for {
+		i := strings.LastIndex(name, ".func")
+		if i < 0 || strings.Trim(name[i+len(".func"):], "0123456789.") != "" {
+			break
+		}
+		name = name[:i]
+	}
+	return name
+}
+
+// As names the route that was registered last, to generate its URL with another name than its
+// controller#action:
+//
+//	r.GetFunc("/login", "sessions", "new", sessions.New).As("login")
+func (r *router) As(name string) Router {
+	if err := r.routes.rename(name); err != nil {
+		return errRouter{err}
+	}
+	return r
+}
+
+// absoluteURL builds the full URL of the named route for a request served by the route current.
+// Routes of the same router keep the host of the request, so tenants stay on their subdomain.
+// Routes of another router use the base domain, or the subdomain given in the subdomain param.
+func (a *action) absoluteURL(req *http.Request, name string, params ...any) (string, error) {
+	p, err := routeParams(params...)
+	if err != nil {
+		return "", err
+	}
+
+	current := a.route
+	route, err := current.router.routes.lookup(name, current.router, current.Version)
+	if err != nil {
+		return "", err
+	}
+
+	host := req.Host
+	if route.Router != current.Router {
+		host = route.router.baseDomain
+		if sub, ok := p["subdomain"]; ok {
+			host = sub + "." + host
+		} else if route.router.isClone {
+			return "", fmt.Errorf("route %s is served on a subdomain, set the subdomain param", name)
+		}
+	}
+	delete(p, "subdomain")
+
+	path, err := route.URL(p)
+	if err != nil {
+		return "", err
+	}
+
+	scheme := "http"
+	if route.router.Secure() {
+		scheme = "https"
+	}
+	return scheme + "://" + host + path, nil
+}
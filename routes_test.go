@@ -0,0 +1,164 @@
+package tracks
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type routeModel struct{ id int }
+
+func (m routeModel) GetID() any { return m.id }
+
+func TestURLFor(t *testing.T) {
+	noop := func(r *http.Request) (any, error) { return nil, nil }
+
+	r := New(t.Context()).
+		GetFunc("/users/", "users", "index", noop).
+		GetFunc("/users/{id}", "users", "show", noop).
+		GetFunc("/files/{path...}", "files", "show", noop).
+		GetFunc("/login", "sessions", "new", noop).As("login")
+
+	v1 := r.Version("v1").GetFunc("/users/{id}", "users", "show", noop)
+
+	t.Run("Fills path values", func(t *testing.T) {
+		u, err := r.URLFor("users#show", "id", 5)
+		require.NoError(t, err)
+		assert.Equal(t, "/users/5", u)
+
+		u, err = r.URLFor("users#show", "id", "a b/c")
+		require.NoError(t, err)
+		assert.Equal(t, "/users/a%20b%2Fc", u)
+
+		u, err = r.URLFor("files#show", "path", "docs/read me.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "/files/docs/read%20me.txt", u)
+	})
+
+	t.Run("Adds the other params to the query string", func(t *testing.T) {
+		u, err := r.URLFor("users#show", "id", 5, "tab", "posts", "page", 2)
+		require.NoError(t, err)
+		assert.Equal(t, "/users/5?page=2&tab=posts", u)
+
+		u, err = r.URLFor("users#index", map[string]any{"q": "tim"})
+		require.NoError(t, err)
+		assert.Equal(t, "/users/?q=tim", u)
+	})
+
+	t.Run("Uses the ID of models", func(t *testing.T) {
+		u, err := r.URLFor("users#show", "id", routeModel{id: 7})
+		require.NoError(t, err)
+		assert.Equal(t, "/users/7", u)
+	})
+
+	t.Run("Uses the overridden name", func(t *testing.T) {
+		u, err := r.URLFor("login")
+		require.NoError(t, err)
+		assert.Equal(t, "/login", u)
+
+		_, err = r.URLFor("sessions#new")
+		assert.ErrorIs(t, err, ErrRouteNotFound)
+	})
+
+	t.Run("Prefers the routes of the version", func(t *testing.T) {
+		u, err := v1.URLFor("users#show", "id", 5)
+		require.NoError(t, err)
+		assert.Equal(t, "/v1/users/5", u)
+
+		u, err = r.URLFor("users#show", "id", 5)
+		require.NoError(t, err)
+		assert.Equal(t, "/users/5", u)
+
+		// Routes outside of the version are found from the version too
+		u, err = v1.URLFor("login")
+		require.NoError(t, err)
+		assert.Equal(t, "/login", u)
+	})
+
+	t.Run("Fails on missing path values and invalid params", func(t *testing.T) {
+		_, err := r.URLFor("users#show")
+		assert.Error(t, err)
+
+		_, err = r.URLFor("users#show", "id")
+		assert.Error(t, err)
+
+		_, err = r.URLFor("unknown#route")
+		assert.ErrorIs(t, err, ErrRouteNotFound)
+	})
+}
+
+func TestURLTemplateFuncs(t *testing.T) {
+	require.NoError(t, writeTemplate("views/layouts/application.gohtml", `{{ template "yield" .Content }}`))
+	require.NoError(t, writeTemplate("views/users/show.gohtml", `{{ path "users#show" "id" . "tab" "posts" }} {{ url "users#show" "id" . }} {{ url "pages#home" }} {{ url "projects#index" "subdomain" "acme" }}`))
+	defer os.RemoveAll("views")
+
+	noop := func(r *http.Request) (any, error) { return nil, nil }
+
+	r := New(t.Context())
+	r.GetFunc("/", "pages", "home", noop)
+	r.Clone().GetFunc("/projects/", "projects", "index", noop)
+	h, err := r.GetFunc("/users/{id}", "users", "show", func(r *http.Request) (any, error) {
+		return r.PathValue("id"), nil
+	}).Handler()
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/3", nil)
+	req.Host = "www.tracks.local:8080"
+	req.Header.Set("Accept", "text/html")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	body, err := io.ReadAll(rr.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rr.Code, string(body))
+	assert.Equal(t, "/users/3?tab=posts http://www.tracks.local:8080/users/3 http://www.tracks.local:8080/ http://acme.tracks.local:8080/projects/", string(body))
+}
+
+func TestRoutes(t *testing.T) {
+	noop := func(r *http.Request) (any, error) { return nil, nil }
+	auth := func(r Router) Middleware {
+		return func(next http.Handler) (http.Handler, error) { return next, nil }
+	}
+
+	r := New(t.Context())
+	r.Serve(Action{Method: http.MethodGet, Path: "/admin", Controller: "admin", Name: "index", Func: noop, Layout: "admin", Middlewares: []MiddlewareBuilder{auth}})
+	r.Version("v1").GetFunc("/users/", "users", "index", noop)
+	r.Clone().GetFunc("/projects/", "projects", "index", noop).As("projects")
+
+	routes := r.Routes()
+	require.Len(t, routes, 3)
+
+	assert.Equal(t, "/admin", routes[0].Path)
+	assert.Equal(t, "admin#index", routes[0].Name)
+	assert.Equal(t, "admin", routes[0].Layout)
+	assert.Equal(t, "root", routes[0].Router)
+	assert.Equal(t, []string{"tracks.TestRoutes"}, routes[0].Middlewares)
+
+	assert.Equal(t, "/projects/", routes[1].Path)
+	assert.Equal(t, "projects", routes[1].Name)
+	assert.Equal(t, "clone-1", routes[1].Router)
+	assert.Equal(t, defaultLayout, routes[1].Layout)
+
+	assert.Equal(t, "/v1/users/", routes[2].Path)
+	assert.Equal(t, http.MethodGet, routes[2].Method)
+	assert.Equal(t, "v1", routes[2].Version)
+	assert.Equal(t, []string{"tracks.versionMiddleware"}, routes[2].Middlewares)
+}
+
+func TestRunWritesRoutes(t *testing.T) {
+	noop := func(r *http.Request) (any, error) { return nil, nil }
+	r := New(t.Context()).GetFunc("/users/", "users", "index", noop)
+
+	file := t.TempDir() + "/routes.json"
+	t.Setenv("TRACKS_ROUTES", file)
+	require.NoError(t, r.Run(t.Context()))
+
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"/users/"`)
+}
@@ -0,0 +1,235 @@
+package tracks
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SecurityConfig configures the security headers that are set on every response
+type SecurityConfig struct {
+	// Disabled turns the security headers off
+	Disabled bool `json:"disabled"`
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header in seconds, one year by
+	// default. The header is only set when the router is Secure.
+	HSTSMaxAge int `json:"hsts_max_age"`
+	// HSTSIncludeSubDomains applies the Strict-Transport-Security header to all subdomains as well,
+	// only enable it when every subdomain is served over HTTPS
+	HSTSIncludeSubDomains bool `json:"hsts_include_subdomains"`
+	// ReferrerPolicy is strict-origin-when-cross-origin by default
+	ReferrerPolicy string `json:"referrer_policy"`
+	// FrameAncestors are the pages that can embed the application in a frame, 'self' by default
+	FrameAncestors string `json:"frame_ancestors"`
+	// PermissionsPolicy disables the camera, microphone and geolocation by default
+	PermissionsPolicy string `json:"permissions_policy"`
+	// CSP overrides the directives of the Content-Security-Policy, an empty value removes a directive.
+	// {nonce} is replaced by the nonce of the request, e.g. "script-src": "'self' {nonce}".
+	CSP map[string]string `json:"csp"`
+	// ReportOnly only reports violations of the Content-Security-Policy instead of blocking them
+	ReportOnly bool `json:"report_only"`
+	// ReportPath is the path violations of the Content-Security-Policy are reported to. A summary of
+	// every report is logged.
+	ReportPath string `json:"report_path"`
+}
+
+const (
+	defaultHSTSMaxAge        = 365 * 24 * 60 * 60
+	defaultReferrerPolicy    = "strict-origin-when-cross-origin"
+	defaultFrameAncestors    = "'self'"
+	defaultPermissionsPolicy = "camera=(), microphone=(), geolocation=()"
+	cspNonceLength           = 16
+	maxCSPReportSize         = 16 << 10
+	maxCSPReportField        = 256
+)
+
+// defaultCSP are the directives of the default Content-Security-Policy. Scripts need the nonce of
+// the request, so inline scripts have to be marked with nonce="{{ csp_nonce }}".
+var defaultCSP = map[string]string{
+	"default-src": "'self'",
+	"script-src":  "'self' {nonce}",
+	"style-src":   "'self' 'unsafe-inline'",
+	"img-src":     "'self' data:",
+	"object-src":  "'none'",
+	"base-uri":    "'self'",
+}
+
+type cspNonceKey struct{}
+
+// CSPNonceFromContext returns the nonce of the Content-Security-Policy of the request
+func CSPNonceFromContext(r *http.Request) string {
+	if nonce, ok := r.Context().Value(cspNonceKey{}).(string); ok {
+		return nonce
+	}
+	return ""
+}
+
+// SecurityHeaders replaces the configuration of the security headers, which are set on every
+// response by default.
+func (r *router) SecurityHeaders(config SecurityConfig) Router {
+	if r.security == nil {
+		r.security = &securityHeaders{}
+		r.GlobalMiddleware(r.security.middleware)
+	}
+	r.security.config = config
+	r.security.secure = r.Secure()
+	return r
+}
+
+// securityHeaders sets the security headers. It's shared by a router and its groups, so the
+// configuration can be changed after the middleware was added.
+type securityHeaders struct {
+	config SecurityConfig
+	secure bool
+}
+
+func (s *securityHeaders) middleware(next http.Handler) (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config := s.config
+		if config.Disabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Reports are answered before CSRF validation, browsers don't send a token
+		if config.ReportPath != "" && r.URL.Path == config.ReportPath && r.Method == http.MethodPost {
+			reportCSPViolation(w, r)
+			return
+		}
+
+		nonce, err := generateNonce()
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), cspNonceKey{}, nonce))
+
+		h := w.Header()
+		if s.secure {
+			maxAge := config.HSTSMaxAge
+			if maxAge == 0 {
+				maxAge = defaultHSTSMaxAge
+			}
+			hsts := "max-age=" + strconv.Itoa(maxAge)
+			if config.HSTSIncludeSubDomains {
+				hsts += "; includeSubDomains"
+			}
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("Referrer-Policy", cmp.Or(config.ReferrerPolicy, defaultReferrerPolicy))
+		h.Set("Permissions-Policy", cmp.Or(config.PermissionsPolicy, defaultPermissionsPolicy))
+
+		if config.ReportOnly {
+			h.Set("Content-Security-Policy-Report-Only", config.policy(nonce))
+		} else {
+			h.Set("Content-Security-Policy", config.policy(nonce))
+		}
+
+		next.ServeHTTP(w, r)
+	}), nil
+}
+
+// generateNonce generates the nonce of the Content-Security-Policy of a request. It's URL-safe, so
+// templates don't escape it in the nonce attribute.
+func generateNonce() (string, error) {
+	b := make([]byte, cspNonceLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// policy builds the Content-Security-Policy for a request with the given nonce
+func (c SecurityConfig) policy(nonce string) string {
+	directives := maps.Clone(defaultCSP)
+	directives["frame-ancestors"] = cmp.Or(c.FrameAncestors, defaultFrameAncestors)
+	if c.ReportPath != "" {
+		directives["report-uri"] = c.ReportPath
+	}
+	for k, v := range c.CSP {
+		directives[k] = v
+	}
+
+	names := make([]string, 0, len(directives))
+	for k, v := range directives {
+		if v != "" {
+			names = append(names, k)
+		}
+	}
+	slices.Sort(names)
+
+	policy := make([]string, 0, len(names))
+	for _, k := range names {
+		policy = append(policy, k+" "+strings.ReplaceAll(directives[k], "{nonce}", "'nonce-"+nonce+"'"))
+	}
+	return strings.Join(policy, "; ")
+}
+
+// cspReport is a violation report, as sent for report-uri. The Reporting API sends a list of
+// reports instead, with the same fields under other names in their body.
+type cspReport struct {
+	DocumentURI       string `json:"document-uri"`
+	ViolatedDirective string `json:"violated-directive"`
+	BlockedURI        string `json:"blocked-uri"`
+}
+
+// reportCSPViolation logs a summary of a Content-Security-Policy violation report. The endpoint
+// doesn't require authentication, so the logged fields are truncated and anything else is left out.
+func reportCSPViolation(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCSPReportSize))
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	var report struct {
+		Report cspReport `json:"csp-report"`
+	}
+	var reports []struct {
+		Body struct {
+			DocumentURL        string `json:"documentURL"`
+			EffectiveDirective string `json:"effectiveDirective"`
+			BlockedURL         string `json:"blockedURL"`
+		} `json:"body"`
+	}
+	switch {
+	case json.Unmarshal(body, &report) == nil:
+	case json.Unmarshal(body, &reports) == nil && len(reports) > 0:
+		report.Report = cspReport{
+			DocumentURI:       reports[0].Body.DocumentURL,
+			ViolatedDirective: reports[0].Body.EffectiveDirective,
+			BlockedURI:        reports[0].Body.BlockedURL,
+		}
+	default:
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	slog.Warn("Content Security Policy violation",
+		"document_uri", truncate(report.Report.DocumentURI, maxCSPReportField),
+		"violated_directive", truncate(report.Report.ViolatedDirective, maxCSPReportField),
+		"blocked_uri", truncate(report.Report.BlockedURI, maxCSPReportField),
+		"user_agent", truncate(r.UserAgent(), maxCSPReportField))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// truncate shortens s to at most n bytes, without splitting a character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,113 @@
+package tracks
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	require.NoError(t, writeTemplate("views/layouts/application.gohtml", `<script nonce="{{ csp_nonce }}">{{ template "yield" .Content }}</script>`))
+	require.NoError(t, writeTemplate("views/pages/home.gohtml", `ok`))
+	defer os.RemoveAll("views")
+
+	noop := func(r *http.Request) (any, error) { return nil, nil }
+
+	serve := func(r Router, req *http.Request) *httptest.ResponseRecorder {
+		h, err := r.Handler()
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Sets the security headers by default", func(t *testing.T) {
+		r := New(t.Context()).GetFunc("/", "pages", "home", noop)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "text/html")
+		rr := serve(r, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "strict-origin-when-cross-origin", rr.Header().Get("Referrer-Policy"))
+		assert.Equal(t, "camera=(), microphone=(), geolocation=()", rr.Header().Get("Permissions-Policy"))
+		assert.Empty(t, rr.Header().Get("Strict-Transport-Security"))
+
+		body, err := io.ReadAll(rr.Body)
+		require.NoError(t, err)
+		nonce := strings.TrimSuffix(strings.TrimPrefix(string(body), `<script nonce="`), `">ok</script>`)
+		require.NotEmpty(t, nonce)
+
+		assert.Equal(t, "base-uri 'self'; default-src 'self'; frame-ancestors 'self'; img-src 'self' data:; object-src 'none'; "+
+			"script-src 'self' 'nonce-"+nonce+"'; style-src 'self' 'unsafe-inline'", rr.Header().Get("Content-Security-Policy"))
+
+		// Every request gets a new nonce
+		rr = serve(r, req)
+		assert.NotContains(t, rr.Header().Get("Content-Security-Policy"), nonce)
+	})
+
+	t.Run("Uses the configuration", func(t *testing.T) {
+		r := New(t.Context()).
+			SecurityHeaders(SecurityConfig{
+				FrameAncestors: "'none'",
+				CSP:            map[string]string{"img-src": "*", "object-src": ""},
+				ReportOnly:     true,
+				ReportPath:     "/csp-reports",
+			}).
+			CSRFProtection(CSRFConfig{}).
+			GetFunc("/", "pages", "home", noop)
+
+		rr := serve(r, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Empty(t, rr.Header().Get("Content-Security-Policy"))
+		policy := rr.Header().Get("Content-Security-Policy-Report-Only")
+		assert.Contains(t, policy, "frame-ancestors 'none'")
+		assert.Contains(t, policy, "img-src *;")
+		assert.Contains(t, policy, "report-uri /csp-reports")
+		assert.NotContains(t, policy, "object-src")
+
+		req := httptest.NewRequest(http.MethodPost, "/csp-reports", strings.NewReader(`{"csp-report":{"violated-directive":"script-src"}}`))
+		req.Header.Set("Content-Type", "application/csp-report")
+		assert.Equal(t, http.StatusNoContent, serve(r, req).Code)
+
+		req = httptest.NewRequest(http.MethodPost, "/csp-reports", strings.NewReader(`[{"type":"csp-violation","body":{"effectiveDirective":"script-src"}}]`))
+		req.Header.Set("Content-Type", "application/reports+json")
+		assert.Equal(t, http.StatusNoContent, serve(r, req).Code)
+
+		req = httptest.NewRequest(http.MethodPost, "/csp-reports", strings.NewReader(strings.Repeat("x", 100)))
+		assert.Equal(t, http.StatusBadRequest, serve(r, req).Code)
+	})
+
+	t.Run("Sets HSTS on secure routers", func(t *testing.T) {
+		r := New(t.Context()).GetFunc("/", "pages", "home", noop)
+		r.(*router).config.Secure = true
+		r.SecurityHeaders(SecurityConfig{HSTSMaxAge: 600})
+
+		rr := serve(r, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, "max-age=600", rr.Header().Get("Strict-Transport-Security"))
+
+		r.SecurityHeaders(SecurityConfig{HSTSIncludeSubDomains: true})
+		rr = serve(r, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, "max-age=31536000; includeSubDomains", rr.Header().Get("Strict-Transport-Security"))
+	})
+
+	t.Run("Can be disabled", func(t *testing.T) {
+		r := New(t.Context()).SecurityHeaders(SecurityConfig{Disabled: true}).GetFunc("/", "pages", "home", noop)
+
+		rr := serve(r, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Empty(t, rr.Header().Get("Content-Security-Policy"))
+		assert.Empty(t, rr.Header().Get("X-Content-Type-Options"))
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "abc...", truncate("abcdef", 3))
+	assert.Equal(t, "a...", truncate("aé", 2))
+}
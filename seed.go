@@ -0,0 +1,47 @@
+package tracks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tmeire/tracks/database"
+	"github.com/tmeire/tracks/database/sqlite"
+)
+
+// seedFromEnv seeds a database instead of starting the server, when `tracks db seed` runs the
+// application with the TRACKS_SEED environment variable set to the database type, see Main. The
+// database of the application is seeded, unless TRACKS_SEED_DB points to another one, which is
+// required for tenant databases.
+//
+// Without TRACKS_SEED_NAMES, the fixtures in fixtures/<type> are loaded before all seeds run.
+func (r *router) seedFromEnv(ctx context.Context, dbType database.Type) error {
+	var names []string
+	if n := os.Getenv("TRACKS_SEED_NAMES"); n != "" {
+		names = strings.Split(n, ",")
+	}
+
+	db := r.database
+	if path := os.Getenv("TRACKS_SEED_DB"); path != "" {
+		sqlDB, err := sqlite.New(path)
+		if err != nil {
+			return err
+		}
+		defer sqlDB.Close()
+		db = sqlDB
+	} else if dbType != database.CentralDatabase {
+		return errors.New("seeding a tenant database requires the path of the database")
+	}
+
+	return database.WithTransaction(database.WithDB(ctx, db), func(ctx context.Context) error {
+		dir := filepath.Join("fixtures", string(dbType))
+		if _, err := os.Stat(dir); err == nil && len(names) == 0 {
+			if err := database.LoadFixtures(ctx, dbType, os.DirFS(dir)); err != nil {
+				return err
+			}
+		}
+		return database.Seed(ctx, database.FromContext(ctx), dbType, names...)
+	})
+}
@@ -0,0 +1,34 @@
+package tracks
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmeire/tracks/database"
+)
+
+func TestMainSeeds(t *testing.T) {
+	// A dedicated type keeps the registrations of this test apart from the others
+	const seedType database.Type = "main_test"
+
+	var seeded []string
+	database.RegisterSeed(seedType, "plans", func(ctx context.Context) error {
+		seeded = append(seeded, "plans")
+		return nil
+	})
+	database.RegisterSeed(seedType, "users", func(ctx context.Context) error {
+		seeded = append(seeded, "users")
+		return nil
+	})
+
+	t.Setenv("TRACKS_SEED", string(seedType))
+	t.Setenv("TRACKS_SEED_NAMES", "users")
+	t.Setenv("TRACKS_SEED_DB", filepath.Join(t.TempDir(), "seed.sqlite"))
+
+	r := New(t.Context())
+	require.NoError(t, Main(t.Context(), r.Group("/admin")))
+	assert.Equal(t, []string{"users"}, seeded)
+}
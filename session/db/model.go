@@ -2,7 +2,6 @@ package db
 
 import (
 	"context"
-	"encoding/json"
 	"github.com/tmeire/tracks/database"
 	"time"
 )
@@ -10,8 +9,8 @@ import (
 // SessionModel represents a session stored in the database
 type SessionModel struct {
 	ID        string
-	Data      string // JSON-encoded session data
-	Flash     string // JSON-encoded flash data
+	Data      database.JSON[map[string]string]
+	Flash     database.JSON[map[string]string]
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -41,49 +40,39 @@ func (s *SessionModel) Scan(_ context.Context, _ *Store, row database.Scanner) (
 	return &model, nil
 }
 
-// UnmarshalData unmarshals the JSON-encoded data into a map
+// UnmarshalData returns the session data
+//
+// Deprecated: use Data.V instead.
 func (s *SessionModel) UnmarshalData() (map[string]string, error) {
-	var data map[string]string
-	if s.Data == "" {
+	if s.Data.V == nil {
 		return make(map[string]string), nil
 	}
-	err := json.Unmarshal([]byte(s.Data), &data)
-	if err != nil {
-		return nil, err
-	}
-	return data, nil
+	return s.Data.V, nil
 }
 
-// MarshalData marshals a map into JSON-encoded data
+// MarshalData sets the session data
+//
+// Deprecated: use Data = database.NewJSON(data) instead.
 func (s *SessionModel) MarshalData(data map[string]string) error {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-	s.Data = string(jsonData)
+	s.Data = database.NewJSON(data)
 	return nil
 }
 
-// UnmarshalFlash unmarshals the JSON-encoded flash data into a map
+// UnmarshalFlash returns the flash data
+//
+// Deprecated: use Flash.V instead.
 func (s *SessionModel) UnmarshalFlash() (map[string]string, error) {
-	var flash map[string]string
-	if s.Flash == "" {
+	if s.Flash.V == nil {
 		return make(map[string]string), nil
 	}
-	err := json.Unmarshal([]byte(s.Flash), &flash)
-	if err != nil {
-		return nil, err
-	}
-	return flash, nil
+	return s.Flash.V, nil
 }
 
-// MarshalFlash marshals a map into JSON-encoded data
+// MarshalFlash sets the flash data
+//
+// Deprecated: use Flash = database.NewJSON(flash) instead.
 func (s *SessionModel) MarshalFlash(flash map[string]string) error {
-	jsonData, err := json.Marshal(flash)
-	if err != nil {
-		return err
-	}
-	s.Flash = string(jsonData)
+	s.Flash = database.NewJSON(flash)
 	return nil
 }
 
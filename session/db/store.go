@@ -48,16 +48,14 @@ func (s *Store) Load(ctx context.Context, id string) (session.Session, bool) {
 		return nil, false
 	}
 
-	// Unmarshal the data
-	data, err := model.UnmarshalData()
-	if err != nil {
-		return nil, false
+	// Sessions stored without data or flash messages scan into nil maps
+	data := model.Data.V
+	if data == nil {
+		data = make(map[string]string)
 	}
-
-	// Unmarshal the flash
-	flash, err := model.UnmarshalFlash()
-	if err != nil {
-		return nil, false
+	flash := model.Flash.V
+	if flash == nil {
+		flash = make(map[string]string)
 	}
 
 	// Create a new session data object
@@ -75,12 +73,6 @@ func (s *Store) Load(ctx context.Context, id string) (session.Session, bool) {
 }
 
 func (s *Store) update(ctx context.Context, d *sessionData) error {
-	model := &SessionModel{
-		ID:        d.Id,
-		CreatedAt: d.createdAt,
-		UpdatedAt: time.Now(),
-	}
-
 	d.mu.RLock()
 	dataCopy := make(map[string]string, len(d.Data))
 	for k, v := range d.Data {
@@ -92,22 +84,18 @@ func (s *Store) update(ctx context.Context, d *sessionData) error {
 	}
 	d.mu.RUnlock()
 
-	// Marshal the data
-	err := model.MarshalData(dataCopy)
-	if err != nil {
-		return err
-	}
-
-	// Marshal the data
-	err = model.MarshalFlash(flashCopy)
-	if err != nil {
-		return err
+	model := &SessionModel{
+		ID:        d.Id,
+		Data:      database.NewJSON(dataCopy),
+		Flash:     database.NewJSON(flashCopy),
+		CreatedAt: d.createdAt,
+		UpdatedAt: time.Now(),
 	}
 
 	ctx = database.WithDB(ctx, s.database)
 
 	// Update in database
-	err = s.repository.Update(ctx, model)
+	err := s.repository.Update(ctx, model)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to update session in database", "session_id", d.Id, "error", err)
 		return err
@@ -130,8 +118,8 @@ func (s *Store) Create(ctx context.Context) session.Session {
 	now := time.Now()
 	model := &SessionModel{
 		ID:        id,
-		Data:      "{}",
-		Flash:     "{}",
+		Data:      database.NewJSON(map[string]string{}),
+		Flash:     database.NewJSON(map[string]string{}),
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -182,8 +170,8 @@ func (s *Store) invalidate(ctx context.Context, d *sessionData) {
 	now := time.Now()
 	newModel := &SessionModel{
 		ID:        d.Id,
-		Data:      "{}",
-		Flash:     "{}",
+		Data:      database.NewJSON(map[string]string{}),
+		Flash:     database.NewJSON(map[string]string{}),
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
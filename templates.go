@@ -91,6 +91,7 @@ func newTemplates(baseDomain string) *Templates {
 			"safe": func(s string) template.HTML {
 				return template.HTML(s)
 			},
+			"method_field": MethodField,
 			"split": func(s, sep string) []string {
 				return strings.Split(s, sep)
 			},
@@ -164,6 +165,9 @@ func newTemplates(baseDomain string) *Templates {
 			"v":          dummyV,
 			"csrf_token": func() string { return "" },
 			"csrf_field": func() template.HTML { return "" },
+			"csp_nonce":  func() string { return "" },
+			"path":       func(name string, params ...any) (string, error) { return "", nil },
+			"url":        func(name string, params ...any) (string, error) { return "", nil },
 		},
 	}
 }
@@ -181,6 +185,16 @@ func (t *Templates) Clone() *Templates {
 	}
 }
 
+// scope returns templates that load their views from basedir, but share the functions of t, so
+// functions that are added later are available to both
+func (t *Templates) scope(basedir string) *Templates {
+	return &Templates{
+		fns:     t.fns,
+		basedir: basedir,
+		layouts: make(map[string]*template.Template),
+	}
+}
+
 // Func adds a new function to templates that are loaded after this call
 func (t *Templates) Func(name string, fn any) {
 	t.fns[name] = fn
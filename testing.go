@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -71,6 +72,22 @@ func (a *TestApp) DB() database.Database {
 	return a.router.Database()
 }
 
+// Seed runs the central database seeds with the given names, or all of them, on the database of
+// the test application.
+func (a *TestApp) Seed(names ...string) {
+	if err := database.Seed(context.Background(), a.DB(), database.CentralDatabase, names...); err != nil {
+		a.t.Fatalf("Failed to seed database: %v", err)
+	}
+}
+
+// LoadFixtures loads the central database fixtures in fsys into the database of the test application.
+func (a *TestApp) LoadFixtures(fsys fs.FS) {
+	ctx := database.WithDB(context.Background(), a.DB())
+	if err := database.LoadFixtures(ctx, database.CentralDatabase, fsys); err != nil {
+		a.t.Fatalf("Failed to load fixtures: %v", err)
+	}
+}
+
 func (a *TestApp) AuthenticateAs(userID string) {
 	a.testUserID = userID
 }
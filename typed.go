@@ -0,0 +1,95 @@
+package tracks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+)
+
+// TypedFunc is an action with a typed input and output, see Typed
+type TypedFunc[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// Typed adapts a typed action to an ActionFunc. Its input is filled from the request with Bind
+// and validated with Validate before fn is called:
+//
+//	r.PostFunc("/users/", "users", "create", tracks.Typed(func(ctx context.Context, in CreateUser) (*User, error) {
+//		return users.Create(ctx, in.Name, in.Email)
+//	}))
+//
+// When the input can't be bound or isn't valid, or fn returns ValidationErrors, the action responds
+// with a ValidationError response instead. Register the action with HandleTyped to record its In
+// and Out types on the route.
+func Typed[In, Out any](fn TypedFunc[In, Out]) ActionFunc {
+	return func(r *http.Request) (any, error) {
+		var in In
+		dst := any(&in)
+
+		// If In is a pointer, we need to allocate it
+		rv := reflect.ValueOf(&in).Elem()
+		if rv.Kind() == reflect.Ptr {
+			rv.Set(reflect.New(rv.Type().Elem()))
+			dst = rv.Interface()
+		}
+
+		if err := Bind(r, dst); err != nil {
+			return typedError(err)
+		}
+		if err := Validate(dst); err != nil {
+			return typedError(err)
+		}
+
+		out, err := fn(r.Context(), in)
+		if err != nil {
+			return typedError(err)
+		}
+		return out, nil
+	}
+}
+
+// typedError turns validation errors into a ValidationError response
+func typedError(err error) (any, error) {
+	var ve ValidationErrors
+	if errors.As(err, &ve) {
+		return ValidationError(ve), nil
+	}
+	return nil, err
+}
+
+// HandleTyped registers a typed action on the router, like Serve with Typed(fn) as its Func. The
+// In and Out types of the action are recorded on its route, for Routes and the OpenAPI document.
+//
+//	tracks.HandleTyped(r, http.MethodPost, "/users/", "users", "create", createUser).
+//		Document(tracks.RouteDoc{Summary: "Create a user"})
+func HandleTyped[In, Out any](r Router, method, path, controller, action string, fn TypedFunc[In, Out], mws ...MiddlewareBuilder) Router {
+	var in In
+	var out Out
+	return r.Serve(Action{
+		Method:      method,
+		Path:        path,
+		Controller:  controller,
+		Name:        action,
+		Func:        Typed(fn),
+		Middlewares: mws,
+		Doc: &RouteDoc{
+			Request:  typedValue(in),
+			Response: typedValue(out),
+		},
+	})
+}
+
+// typedValue returns a value of type T for a RouteDoc, where pointers are allocated and empty
+// structs aren't documented
+func typedValue[T any](v T) any {
+	t := reflect.TypeOf(&v).Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct && t.NumField() == 0 {
+		return nil
+	}
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+	return reflect.New(t).Elem().Interface()
+}
@@ -0,0 +1,98 @@
+package tracks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type CreateNote struct {
+	Folder int    `path:"folder"`
+	Title  string `json:"title" form:"title" validate:"required,max=10"`
+}
+
+type Note struct {
+	Folder int    `json:"folder"`
+	Title  string `json:"title"`
+}
+
+func createNote(ctx context.Context, in *CreateNote) (Note, error) {
+	switch in.Title {
+	case "taken":
+		return Note{}, ValidationErrors{"title": {"is already taken"}}
+	case "fail":
+		return Note{}, errors.New("storage failed")
+	}
+	return Note{Folder: in.Folder, Title: in.Title}, nil
+}
+
+func TestTyped(t *testing.T) {
+	r := HandleTyped(New(t.Context()), http.MethodPost, "/folders/{folder}/notes", "notes", "create", createNote).
+		Document(RouteDoc{Summary: "Create a note"})
+
+	h, err := r.Handler()
+	require.NoError(t, err)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/folders/3/notes", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Binds the input and returns the output", func(t *testing.T) {
+		rr := post(`{"title":"Groceries"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"folder":3,"title":"Groceries"}`, rr.Body.String())
+	})
+
+	t.Run("Responds with validation errors", func(t *testing.T) {
+		for body, errs := range map[string]map[string][]string{
+			`{"title":""}`:             {"title": {"field is required"}},
+			`{"title":"Far too long"}`: {"title": {"maximum length is 10"}},
+			`{"title":5}`:              {"title": {"must be a string"}},
+			`{"title":"taken"}`:        {"title": {"is already taken"}},
+		} {
+			rr := post(body)
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, body)
+
+			var data ErrorData
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &data))
+			assert.Equal(t, "VALIDATION_ERROR", data.Code)
+			assert.Equal(t, errs, data.Errors, body)
+		}
+	})
+
+	t.Run("Passes other errors on", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, post(`{"title":"fail"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, post(`{"title":`).Code)
+	})
+
+	t.Run("Records the types on the route", func(t *testing.T) {
+		routes := r.Routes()
+		require.Len(t, routes, 1)
+		assert.Equal(t, "tracks.CreateNote", routes[0].Request)
+		assert.Equal(t, "tracks.Note", routes[0].Response)
+		assert.Equal(t, "Create a note", routes[0].Doc.Summary)
+	})
+
+	t.Run("Records the types on groups", func(t *testing.T) {
+		r := New(t.Context())
+		HandleTyped(r.Group("/admin"), http.MethodPut, "/notes", "notes", "update", createNote)
+
+		routes := r.Routes()
+		require.Len(t, routes, 1)
+		assert.Equal(t, "/admin/notes", routes[0].Path)
+		assert.Equal(t, "tracks.CreateNote", routes[0].Request)
+		assert.Equal(t, "tracks.Note", routes[0].Response)
+	})
+}
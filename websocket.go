@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"strings"
+	"slices"
 	"sync"
 
 	"golang.org/x/net/websocket"
@@ -75,8 +75,9 @@ func (h *WebSocketHub) Broadcast(room string, data any) error {
 }
 
 func (r *router) WebSocket(path string, handler WebSocketHandler, mws ...MiddlewareBuilder) Router {
-	if !strings.HasPrefix(path, "/") {
-		path = "/" + path
+	path = r.path(path)
+	if len(r.middlewares) > 0 {
+		mws = append(slices.Clone(r.middlewares), mws...)
 	}
 
 	wsHandler := websocket.Handler(func(ws *websocket.Conn) {